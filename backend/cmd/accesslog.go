@@ -0,0 +1,111 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/handlers"
+	cfg "github.com/kubernetes-sigs/headlamp/backend/pkg/config"
+	"github.com/kubernetes-sigs/headlamp/backend/pkg/logger"
+	"github.com/kubernetes-sigs/headlamp/backend/pkg/utils"
+)
+
+// newAccessLogHandler logs every request handled by next in the given
+// access-log-format. Credentials, like the Authorization header, are never
+// logged, and the values of the redacted query parameters are replaced.
+func newAccessLogHandler(format string, redactParams []string, next http.Handler) http.Handler {
+	return handlers.CustomLoggingHandler(io.Discard, next, func(_ io.Writer, params handlers.LogFormatterParams) {
+		if format == cfg.AccessLogFormatJSON {
+			logger.Log(logger.LevelInfo, accessLogFields(params, redactParams), nil, "access")
+
+			return
+		}
+
+		logger.Log(logger.LevelInfo, nil, nil, combinedLogLine(params, redactParams))
+	})
+}
+
+// accessLogFields returns the fields of the json access log of a request.
+func accessLogFields(params handlers.LogFormatterParams, redactParams []string) map[string]string {
+	r := params.Request
+
+	return map[string]string{
+		"remoteAddr": remoteHost(r),
+		"method":     r.Method,
+		"uri":        redactRequestURI(params.URL, redactParams),
+		"proto":      r.Proto,
+		"status":     strconv.Itoa(params.StatusCode),
+		"size":       strconv.Itoa(params.Size),
+		"referer":    redactURL(r.Referer(), redactParams),
+		"userAgent":  r.UserAgent(),
+		"durationMs": strconv.FormatInt(time.Since(params.TimeStamp).Milliseconds(), 10),
+	}
+}
+
+// combinedLogLine formats a request in the Apache Combined Log Format, with
+// the user always logged as "-".
+func combinedLogLine(params handlers.LogFormatterParams, redactParams []string) string {
+	r := params.Request
+
+	return fmt.Sprintf("%s - - [%s] %q %d %d %q %q",
+		remoteHost(r),
+		params.TimeStamp.Format("02/Jan/2006:15:04:05 -0700"),
+		r.Method+" "+redactRequestURI(params.URL, redactParams)+" "+r.Proto,
+		params.StatusCode,
+		params.Size,
+		redactURL(r.Referer(), redactParams),
+		r.UserAgent(),
+	)
+}
+
+// remoteHost returns the client IP of a request, without its port.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// redactURL returns rawURL with the values of the redacted query parameters
+// replaced, or rawURL as is if it cannot be parsed.
+func redactURL(rawURL string, redactParams []string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" {
+		return rawURL
+	}
+
+	u.RawQuery = utils.RedactQuery(u.RawQuery, redactParams)
+
+	return u.String()
+}
+
+// redactRequestURI returns the path and query of u, with the values of the
+// redacted query parameters replaced.
+func redactRequestURI(u url.URL, redactParams []string) string {
+	u.RawQuery = utils.RedactQuery(u.RawQuery, redactParams)
+
+	return u.RequestURI()
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cfg "github.com/kubernetes-sigs/headlamp/backend/pkg/config"
+	"github.com/kubernetes-sigs/headlamp/backend/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessLogHandler(t *testing.T) {
+	type entry struct {
+		fields map[string]string
+		msg    string
+	}
+
+	var entries []entry
+
+	originalLogFunc := logger.SetLogFunc(func(_ uint, str map[string]string, _ interface{}, msg string) {
+		entries = append(entries, entry{fields: str, msg: msg})
+	})
+	defer logger.SetLogFunc(originalLogFunc)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = w.Write([]byte("hello"))
+	})
+
+	request := func() *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/oidc-callback?code=secret&state=xyz&x=1", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("Authorization", "Bearer secret-token")
+		req.Header.Set("User-Agent", "test-agent")
+
+		return req
+	}
+
+	params := []string{"code", "state"}
+
+	newAccessLogHandler(cfg.AccessLogFormatCombined, params, next).ServeHTTP(httptest.NewRecorder(), request())
+	require.Len(t, entries, 1)
+	assert.Contains(t, entries[0].msg, `10.0.0.1 - - [`)
+	assert.Contains(t, entries[0].msg,
+		`"GET /oidc-callback?code=REDACTED&state=REDACTED&x=1 HTTP/1.1" 418 5 "" "test-agent"`)
+	assert.NotContains(t, entries[0].msg, "secret")
+
+	newAccessLogHandler(cfg.AccessLogFormatJSON, params, next).ServeHTTP(httptest.NewRecorder(), request())
+	require.Len(t, entries, 2)
+	assert.Equal(t, "access", entries[1].msg)
+	assert.Equal(t, "10.0.0.1", entries[1].fields["remoteAddr"])
+	assert.Equal(t, "/oidc-callback?code=REDACTED&state=REDACTED&x=1", entries[1].fields["uri"])
+	assert.Equal(t, "418", entries[1].fields["status"])
+	assert.Equal(t, "5", entries[1].fields["size"])
+
+	for _, value := range entries[1].fields {
+		assert.NotContains(t, value, "secret")
+	}
+}
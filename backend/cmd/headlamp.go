@@ -22,23 +22,31 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/base64"
+	"encoding/gob"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
+	"net"
 	"net/http"
+	"net/netip"
 	"net/url"
 	"os"
+	"os/signal"
 	"path"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	oidc "github.com/coreos/go-oidc/v3/oidc"
-	"github.com/gobwas/glob"
 	"github.com/google/uuid"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
@@ -56,10 +64,13 @@ import (
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/tools/pager"
 
 	"golang.org/x/oauth2"
 )
@@ -74,19 +85,51 @@ type HeadlampConfig struct {
 	watchPluginsChanges       bool
 	port                      uint
 	kubeConfigPath            string
-	skippedKubeContexts       string
+	skippedKubeContexts       map[string]struct{}
+	defaultCluster            string
 	staticDir                 string
 	pluginDir                 string
 	staticPluginDir           string
+	staticCacheControl        string
+	tempDir                   string
 	oidcClientID              string
+	oidcValidatorClientIDs    []string
 	oidcValidatorClientID     string
 	oidcClientSecret          string
 	oidcIdpIssuerURL          string
 	oidcValidatorIdpIssuerURL string
+	oidcValidatorIssuer       string
+	oidcIssuerAliases         []string
 	oidcUseAccessToken        bool
+	oidcUserInfoEnabled       bool
+	oidcGroupsFromUserInfo    bool
+	oidcSessionTTL            time.Duration
+	oidcMaxTokenSize          cfg.ByteSize
+	oidcAllowedRedirectHosts  []string
+	oidcTLSServerName         string
+	oidcProviders             *oidcProviderCache
+	cookieEncryptionKey       []byte
+	sessionCache              cache.Cache[interface{}]
 	baseURL                   string
 	oidcScopes                []string
 	proxyURLs                 []string
+	proxyURLsFile             string
+	proxyURLsRefresh          time.Duration
+	proxyStripResponseHeaders []string
+	proxyAddRequestHeaders    map[string]string
+	blockPrivateProxyTargets  bool
+	allowPrivateProxyTargets  []netip.Prefix
+	listChunkSize             int
+	startupWatchdog           *startupWatchdog
+	exit                      func(code int)
+	watchDrainTimeout         time.Duration
+	globalRequestTimeout      time.Duration
+	perUserRateLimit          float64
+	perUserRateBurst          int
+	perUserRateTrustedProxies []netip.Prefix
+	accessLog                 bool
+	accessLogFormat           string
+	logRedactParams           []string
 	cache                     cache.Cache[interface{}]
 	kubeConfigStore           kubeconfig.ContextStore
 	multiplexer               *Multiplexer
@@ -94,6 +137,10 @@ type HeadlampConfig struct {
 	metrics                   *telemetry.Metrics
 	telemetryConfig           cfg.Config
 	telemetryHandler          *telemetry.RequestHandler
+
+	// proxyURLsFromFile holds the allowed proxy URLs last read from
+	// proxyURLsFile, swapped on each reload.
+	proxyURLsFromFile atomic.Pointer[[]string]
 }
 
 const DrainNodeCacheTTL = 20 // seconds
@@ -106,6 +153,10 @@ const ContextUpdateCacheTTL = 20 * time.Second // seconds
 
 const JWTExpirationTTL = 10 * time.Second // seconds
 
+// oidcRefreshTokenTTL is how long the refresh token of an OIDC login is kept
+// in the session store after its token expired.
+const oidcRefreshTokenTTL = 24 * time.Hour
+
 const kubeConfigSource = "kubeconfig" // source for kubeconfig contexts
 
 const (
@@ -118,18 +169,91 @@ const (
 type clientConfig struct {
 	Clusters                []Cluster `json:"clusters"`
 	IsDynamicClusterEnabled bool      `json:"isDynamicClusterEnabled"`
+	// ListChunkSize is the page size clients should use for list requests, 0 means no chunking.
+	ListChunkSize int `json:"listChunkSize"`
+	// DefaultCluster is the cluster active by default, empty if there is none.
+	DefaultCluster string `json:"defaultCluster,omitempty"`
 }
 
 type spaHandler struct {
 	staticPath string
 	indexPath  string
 	baseURL    string
+	// cacheControl is the Cache-Control header for assets. The index is always
+	// served with no-cache so new deployments are picked up.
+	cacheControl string
 }
 
 type OauthConfig struct {
 	Config   *oauth2.Config
 	Verifier *oidc.IDTokenVerifier
+	Provider *oidc.Provider
 	Ctx      context.Context
+	// AllowedIssuers is set when the verifier skips its own issuer check,
+	// and lists the iss claim values accepted for the ID token.
+	AllowedIssuers []string
+	// AllowedAudiences is set when the verifier skips its own client ID check,
+	// and lists the client IDs accepted in the aud claim of the ID token.
+	AllowedAudiences []string
+}
+
+// verifyIDToken verifies the raw ID token and, when issuer aliases or several
+// validator client IDs are configured, checks its iss and aud claims.
+func (o *OauthConfig) verifyIDToken(rawIDToken string) (*oidc.IDToken, error) {
+	idToken, err := o.Verifier.Verify(o.Ctx, rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(o.AllowedIssuers) > 0 && !slices.Contains(o.AllowedIssuers, idToken.Issuer) {
+		return nil, fmt.Errorf("id token issued by %q, expected one of %v", idToken.Issuer, o.AllowedIssuers)
+	}
+
+	if len(o.AllowedAudiences) > 0 && !slices.ContainsFunc(idToken.Audience, func(audience string) bool {
+		return slices.Contains(o.AllowedAudiences, audience)
+	}) {
+		return nil, fmt.Errorf("id token audience %v does not match any of %v", idToken.Audience, o.AllowedAudiences)
+	}
+
+	return idToken, nil
+}
+
+// withUserInfoClaims fetches the UserInfo claims of the token's user, and adds
+// those missing from the ID token claims. When oidc-groups-from-userinfo is set,
+// the groups claim of UserInfo also replaces the one of the ID token, as some
+// providers leave groups out of their tokens.
+func (c *HeadlampConfig) withUserInfoClaims(o *OauthConfig, token *oauth2.Token, idToken *oidc.IDToken,
+	claims json.RawMessage,
+) (json.RawMessage, error) {
+	userInfo, err := o.Provider.UserInfo(o.Ctx, oauth2.StaticTokenSource(token))
+	if err != nil {
+		return nil, err
+	}
+
+	// The UserInfo response may only be used for the user of the ID token,
+	// see section 5.3.2 of OpenID Connect Core.
+	if userInfo.Subject != idToken.Subject {
+		return nil, fmt.Errorf("userinfo subject %q does not match the id token subject %q",
+			userInfo.Subject, idToken.Subject)
+	}
+
+	var merged, userInfoClaims map[string]json.RawMessage
+
+	if err := json.Unmarshal(claims, &merged); err != nil {
+		return nil, err
+	}
+
+	if err := userInfo.Claims(&userInfoClaims); err != nil {
+		return nil, err
+	}
+
+	for name, value := range userInfoClaims {
+		if _, ok := merged[name]; !ok || (name == "groups" && c.oidcGroupsFromUserInfo) {
+			merged[name] = value
+		}
+	}
+
+	return json.Marshal(merged)
 }
 
 func (h spaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -162,10 +286,12 @@ func (h spaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// check whether a file exists at the given path
-	_, err = os.Stat(path)
+	info, err := os.Stat(path)
 	if os.IsNotExist(err) {
 		// file does not exist, serve index.html
+		w.Header().Set("Cache-Control", "no-cache")
 		http.ServeFile(w, r, filepath.Join(absStaticPath, h.indexPath))
+
 		return
 	} else if err != nil {
 		// if we got an error (that wasn't that the file doesn't exist) stating the
@@ -176,6 +302,12 @@ func (h spaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if info.IsDir() || filepath.Base(path) == h.indexPath {
+		w.Header().Set("Cache-Control", "no-cache")
+	} else if h.cacheControl != "" {
+		w.Header().Set("Cache-Control", h.cacheControl)
+	}
+
 	// The file does exist, so we serve that.
 	http.ServeFile(w, r, path)
 }
@@ -270,6 +402,35 @@ func getOidcCallbackURL(r *http.Request, config *HeadlampConfig) string {
 	return fmt.Sprintf("%s://%s/oidc-callback", urlScheme, hostWithBaseURL)
 }
 
+// isAllowedRedirect returns true if target is a path on the same host, or if
+// its host is in oidcAllowedRedirectHosts. In dev mode, where the frontend
+// runs apart, localhost is allowed as well. When no hosts are configured, any
+// host is allowed, as Headlamp does not know the hosts it is served under,
+// e.g. behind an ingress.
+func (c *HeadlampConfig) isAllowedRedirect(target string) bool {
+	u, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+
+	// Browsers read "//host" and "/\host" as a host, not as a path.
+	if u.Scheme == "" && u.Host == "" {
+		return !strings.HasPrefix(target, "//") && !strings.HasPrefix(target, "/\\")
+	}
+
+	if len(c.oidcAllowedRedirectHosts) == 0 || (c.devMode && strings.EqualFold(u.Hostname(), "localhost")) {
+		return true
+	}
+
+	for _, host := range c.oidcAllowedRedirectHosts {
+		if strings.EqualFold(u.Hostname(), host) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func serveWithNoCacheHeader(fs http.Handler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Add("Cache-Control", "no-cache")
@@ -404,6 +565,51 @@ func addPluginListRoute(config *HeadlampConfig, r *mux.Router) {
 	}).Methods("GET")
 }
 
+// isProxyURLAllowed returns true if target matches one of the proxy-urls or
+// the entries last read from proxy-urls-file.
+func (c *HeadlampConfig) isProxyURLAllowed(target string) bool {
+	proxyURLs := c.proxyURLs
+	if fromFile := c.proxyURLsFromFile.Load(); fromFile != nil {
+		proxyURLs = append(slices.Clone(proxyURLs), *fromFile...)
+	}
+
+	return cfg.MatchProxyURL(proxyURLs, target)
+}
+
+// loadProxyURLsFile reads proxyURLsFile and, if it is valid, replaces the
+// allowed proxy URLs from the previous load.
+func (c *HeadlampConfig) loadProxyURLsFile() error {
+	proxyURLs, err := cfg.LoadProxyURLsFile(c.proxyURLsFile)
+	if err != nil {
+		return err
+	}
+
+	c.proxyURLsFromFile.Store(&proxyURLs)
+
+	return nil
+}
+
+// watchProxyURLsFile reloads proxyURLsFile every proxyURLsRefresh. A file that
+// fails to load is logged and the previously loaded entries are kept.
+func (c *HeadlampConfig) watchProxyURLsFile() {
+	ticker := time.NewTicker(c.proxyURLsRefresh)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := c.loadProxyURLsFile(); err != nil {
+			logger.Log(logger.LevelError, map[string]string{"file": c.proxyURLsFile},
+				err, "reloading proxy URLs, keeping the previous ones")
+
+			continue
+		}
+
+		logger.Log(logger.LevelInfo, map[string]string{
+			"file":  c.proxyURLsFile,
+			"count": fmt.Sprint(len(*c.proxyURLsFromFile.Load())),
+		}, nil, "reloaded proxy URLs")
+	}
+}
+
 //nolint:gocognit,funlen,gocyclo
 func createHeadlampHandler(config *HeadlampConfig) http.Handler {
 	kubeConfigPath := config.kubeConfigPath
@@ -419,9 +625,25 @@ func createHeadlampHandler(config *HeadlampConfig) http.Handler {
 	logger.Log(logger.LevelInfo, nil, nil, "Helm support: "+fmt.Sprint(config.enableHelm))
 	logger.Log(logger.LevelInfo, nil, nil, "Proxy URLs: "+fmt.Sprint(config.proxyURLs))
 
+	if config.proxyURLsFile != "" {
+		if err := config.loadProxyURLsFile(); err != nil {
+			logger.Log(logger.LevelError, map[string]string{"file": config.proxyURLsFile}, err, "loading proxy URLs")
+		} else {
+			logger.Log(logger.LevelInfo, nil, nil, "Proxy URLs from file: "+fmt.Sprint(*config.proxyURLsFromFile.Load()))
+		}
+
+		if config.proxyURLsRefresh > 0 {
+			go config.watchProxyURLsFile()
+		}
+	}
+
 	plugins.PopulatePluginsCache(config.staticPluginDir, config.pluginDir, config.cache)
 
-	skipFunc := kubeconfig.SkipKubeContextInCommaSeparatedString(config.skippedKubeContexts)
+	skipFunc := func(kubeContext kubeconfig.Context) bool {
+		_, ok := config.skippedKubeContexts[kubeContext.Name]
+
+		return ok
+	}
 
 	if !config.useInCluster || config.watchPluginsChanges {
 		// in-cluster mode is unlikely to want reloading plugins.
@@ -467,6 +689,19 @@ func createHeadlampHandler(config *HeadlampConfig) http.Handler {
 		r = baseRoute.PathPrefix(config.baseURL).Subrouter()
 	}
 
+	// Trace and count the requests served, except for metrics-exclude-paths.
+	// As mux runs them once a route matched, the cluster of the request is
+	// known to label them with.
+	if config.telemetry != nil && config.metrics != nil {
+		r.Use(telemetry.TracingMiddleware("headlamp-server"))
+
+		if !config.telemetryConfig.TelemetryExcludeClusterLabel {
+			r.Use(telemetry.ClusterContextMiddleware(config.clusterContextName))
+		}
+
+		r.Use(config.metrics.RequestCounterMiddleware)
+	}
+
 	fmt.Println("*** Headlamp Server ***")
 	fmt.Println("  API Routers:")
 
@@ -523,17 +758,7 @@ func createHeadlampHandler(config *HeadlampConfig) http.Handler {
 			return
 		}
 
-		isURLContainedInProxyURLs := false
-
-		for _, proxyURL := range config.proxyURLs {
-			g := glob.MustCompile(proxyURL)
-			if g.Match(url.String()) {
-				isURLContainedInProxyURLs = true
-				break
-			}
-		}
-
-		if !isURLContainedInProxyURLs {
+		if !config.isProxyURLAllowed(url.String()) {
 			logger.Log(logger.LevelError, nil, err, "no allowed proxy url match, request denied")
 			http.Error(w, "no allowed proxy url match, request denied ", http.StatusBadRequest)
 
@@ -556,6 +781,10 @@ func createHeadlampHandler(config *HeadlampConfig) http.Handler {
 			proxyReq.Header[h] = val
 		}
 
+		for h, val := range config.proxyAddRequestHeaders {
+			proxyReq.Header.Set(h, val)
+		}
+
 		// Disable caching
 		w.Header().Set("Cache-Control", "no-cache, private, max-age=0")
 		w.Header().Set("Expires", time.Unix(0, 0).Format(http.TimeFormat))
@@ -563,8 +792,18 @@ func createHeadlampHandler(config *HeadlampConfig) http.Handler {
 		w.Header().Set("X-Accel-Expires", "0")
 
 		client := http.Client{}
+		if config.blockPrivateProxyTargets {
+			client.Transport = newPrivateIPGuardTransport(config.allowPrivateProxyTargets)
+		}
 
 		resp, err := client.Do(proxyReq)
+		if errors.Is(err, errPrivateProxyTarget) {
+			logger.Log(logger.LevelError, map[string]string{"proxyURL": proxyURL}, err, "private proxy target refused")
+			http.Error(w, "proxy target is a private address, request denied", http.StatusForbidden)
+
+			return
+		}
+
 		if err != nil {
 			logger.Log(logger.LevelError, nil, err, "making request")
 			http.Error(w, err.Error(), http.StatusBadGateway)
@@ -621,15 +860,8 @@ func createHeadlampHandler(config *HeadlampConfig) http.Handler {
 	oauthRequestMap := make(map[string]*OauthConfig)
 
 	r.HandleFunc("/oidc", func(w http.ResponseWriter, r *http.Request) {
-		ctx := context.Background()
+		ctx := config.oidcClientContext(context.Background())
 		cluster := r.URL.Query().Get("cluster")
-		if config.insecure {
-			tr := &http.Transport{
-				TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint:gosec
-			}
-			insecureClient := &http.Client{Transport: tr}
-			ctx = oidc.ClientContext(ctx, insecureClient)
-		}
 
 		kContext, err := config.kubeConfigStore.GetContext(cluster)
 		if err != nil {
@@ -653,7 +885,7 @@ func createHeadlampHandler(config *HeadlampConfig) http.Handler {
 			ctx = oidc.InsecureIssuerURLContext(ctx, config.oidcValidatorIdpIssuerURL)
 		}
 
-		provider, err := oidc.NewProvider(ctx, oidcAuthConfig.IdpIssuerURL)
+		provider, err := config.oidcProviders.get(ctx, oidcAuthConfig.IdpIssuerURL, config.oidcValidatorIdpIssuerURL)
 		if err != nil {
 			logger.Log(logger.LevelError, map[string]string{"idpIssuerURL": oidcAuthConfig.IdpIssuerURL},
 				err, "failed to get provider")
@@ -662,27 +894,76 @@ func createHeadlampHandler(config *HeadlampConfig) http.Handler {
 			return
 		}
 
-		validatorClientID := oidcAuthConfig.ClientID
-		if config.oidcValidatorClientID != "" {
-			validatorClientID = config.oidcValidatorClientID
+		// The validator settings are only set in-cluster, other contexts use
+		// the client ID and issuer of their kubeconfig.
+		validatorClientID := config.oidcValidatorClientID
+		if validatorClientID == "" {
+			validatorClientID = oidcAuthConfig.ClientID
 		}
+
 		oidcConfig := &oidc.Config{
 			ClientID: validatorClientID,
 		}
 
+		var allowedAudiences []string
+
+		if len(config.oidcValidatorClientIDs) > 1 {
+			// The verifier only knows a single client ID, so the aud claim is
+			// checked against all of them after verification instead.
+			oidcConfig.SkipClientIDCheck = true
+			allowedAudiences = config.oidcValidatorClientIDs
+		}
+
+		var allowedIssuers []string
+
+		if len(config.oidcIssuerAliases) > 0 {
+			expectedIssuer := config.oidcValidatorIssuer
+			if expectedIssuer == "" {
+				expectedIssuer = oidcAuthConfig.IdpIssuerURL
+			}
+
+			// The verifier only knows a single issuer, so the iss claim is
+			// checked against the aliases after verification instead.
+			oidcConfig.SkipIssuerCheck = true
+			allowedIssuers = append([]string{expectedIssuer}, config.oidcIssuerAliases...)
+		}
+
+		callbackURL := getOidcCallbackURL(r, config)
+		if !config.isAllowedRedirect(callbackURL) {
+			logger.Log(logger.LevelError, map[string]string{"host": r.Host}, nil, "OIDC callback host not allowed")
+			http.Error(w, "host not allowed by oidc-allowed-redirect-hosts", http.StatusBadRequest)
+
+			return
+		}
+
 		verifier := provider.Verifier(oidcConfig)
 		oauthConfig := &oauth2.Config{
 			ClientID:     oidcAuthConfig.ClientID,
 			ClientSecret: oidcAuthConfig.ClientSecret,
 			Endpoint:     provider.Endpoint(),
-			RedirectURL:  getOidcCallbackURL(r, config),
+			RedirectURL:  callbackURL,
 			Scopes:       append([]string{oidc.ScopeOpenID}, oidcAuthConfig.Scopes...),
 		}
 		/* we encode the cluster to base64 and set it as state so that when getting redirected
 		by oidc we can use this state value to get cluster name
 		*/
 		state := base64.StdEncoding.EncodeToString([]byte(cluster))
-		oauthRequestMap[state] = &OauthConfig{Config: oauthConfig, Verifier: verifier, Ctx: ctx}
+		oauthRequestMap[state] = &OauthConfig{
+			Config:           oauthConfig,
+			Verifier:         verifier,
+			Provider:         provider,
+			Ctx:              ctx,
+			AllowedIssuers:   allowedIssuers,
+			AllowedAudiences: allowedAudiences,
+		}
+
+		if err := config.setOIDCStateCookie(w, callbackURL, state); err != nil {
+			logger.Log(logger.LevelError, nil, err, "setting OIDC state cookie")
+			http.Error(w, "failed to set OIDC state cookie", http.StatusInternalServerError)
+
+			return
+		}
+
 		http.Redirect(w, r, oauthConfig.AuthCodeURL(state), http.StatusFound)
 	}).Queries("cluster", "{cluster}")
 
@@ -723,6 +1004,13 @@ func createHeadlampHandler(config *HeadlampConfig) http.Handler {
 			return
 		}
 
+		if err := config.checkOIDCStateCookie(w, r, state); err != nil {
+			logger.Log(logger.LevelWarn, nil, err, "rejecting OIDC callback")
+			http.Error(w, "invalid request "+err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
 		//nolint:nestif
 		if oauthConfig, ok := oauthRequestMap[state]; ok {
 			oauth2Token, err := oauthConfig.Config.Exchange(oauthConfig.Ctx, r.URL.Query().Get("code"))
@@ -746,15 +1034,14 @@ func createHeadlampHandler(config *HeadlampConfig) http.Handler {
 				return
 			}
 
-			if err := config.cache.Set(context.Background(),
-				fmt.Sprintf("oidc-token-%s", rawUserToken), oauth2Token.RefreshToken); err != nil {
-				logger.Log(logger.LevelError, nil, err, "failed to cache refresh token")
-				http.Error(w, "Failed to cache refresh token: "+err.Error(), http.StatusInternalServerError)
+			if err := config.checkOIDCTokenSize(rawUserToken); err != nil {
+				logger.Log(logger.LevelError, nil, err, "rejecting OIDC token")
+				http.Error(w, err.Error(), http.StatusBadRequest)
 
 				return
 			}
 
-			idToken, err := oauthConfig.Verifier.Verify(oauthConfig.Ctx, rawUserToken)
+			idToken, err := oauthConfig.verifyIDToken(rawUserToken)
 			if err != nil {
 				logger.Log(logger.LevelError, nil, err, "failed to verify ID Token")
 				http.Error(w, "Failed to verify ID Token: "+err.Error(), http.StatusInternalServerError)
@@ -762,6 +1049,21 @@ func createHeadlampHandler(config *HeadlampConfig) http.Handler {
 				return
 			}
 
+			if err := config.sessions().SetWithTTL(context.Background(), fmt.Sprintf("oidc-token-%s", rawUserToken),
+				oauth2Token.RefreshToken, refreshTokenTTL(oauth2Token.Expiry)); err != nil {
+				logger.Log(logger.LevelError, nil, err, "failed to cache refresh token")
+				http.Error(w, "Failed to cache refresh token: "+err.Error(), http.StatusInternalServerError)
+
+				return
+			}
+
+			if err := config.startOIDCSession(rawUserToken); err != nil {
+				logger.Log(logger.LevelError, nil, err, "failed to cache OIDC session")
+				http.Error(w, "Failed to cache OIDC session: "+err.Error(), http.StatusInternalServerError)
+
+				return
+			}
+
 			resp := struct {
 				OAuth2Token   *oauth2.Token
 				IDTokenClaims *json.RawMessage // ID Token payload is just JSON.
@@ -774,6 +1076,18 @@ func createHeadlampHandler(config *HeadlampConfig) http.Handler {
 				return
 			}
 
+			if config.oidcUserInfoEnabled {
+				claims, err := config.withUserInfoClaims(oauthConfig, oauth2Token, idToken, *resp.IDTokenClaims)
+				if err != nil {
+					logger.Log(logger.LevelError, nil, err, "failed to get userinfo claims")
+					http.Error(w, "Failed to get UserInfo claims: "+err.Error(), http.StatusInternalServerError)
+
+					return
+				}
+
+				*resp.IDTokenClaims = claims
+			}
+
 			var redirectURL string
 			if config.devMode {
 				redirectURL = "http://localhost:3000/"
@@ -787,6 +1101,14 @@ func createHeadlampHandler(config *HeadlampConfig) http.Handler {
 			}
 
 			redirectURL += fmt.Sprintf("auth?cluster=%1s&token=%2s", decodedState, rawUserToken)
+
+			if !config.isAllowedRedirect(redirectURL) {
+				logger.Log(logger.LevelError, nil, nil, "OIDC redirect host not allowed")
+				http.Error(w, "redirect host not allowed by oidc-allowed-redirect-hosts", http.StatusBadRequest)
+
+				return
+			}
+
 			http.Redirect(w, r, redirectURL, http.StatusSeeOther)
 		} else {
 			http.Error(w, "invalid request", http.StatusBadRequest)
@@ -805,7 +1127,12 @@ func createHeadlampHandler(config *HeadlampConfig) http.Handler {
 			}
 		}
 
-		spa := spaHandler{staticPath: staticPath, indexPath: "index.html", baseURL: config.baseURL}
+		spa := spaHandler{
+			staticPath:   staticPath,
+			indexPath:    "index.html",
+			baseURL:      config.baseURL,
+			cacheControl: config.staticCacheControl,
+		}
 		r.PathPrefix("/").Handler(spa)
 
 		http.Handle("/", r)
@@ -876,16 +1203,32 @@ func isTokenAboutToExpire(token string) bool {
 	return time.Until(expiryTime) <= JWTExpirationTTL
 }
 
-func refreshAndCacheNewToken(clientID, clientSecret string, cache cache.Cache[interface{}],
-	tokenType, token, issuerURL string,
+// oidcClientContext returns ctx with the HTTP client to reach the OIDC
+// provider with, if insecure or oidcTLSServerName need a custom one.
+func (c *HeadlampConfig) oidcClientContext(ctx context.Context) context.Context {
+	if !c.insecure && c.oidcTLSServerName == "" {
+		return ctx
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{ //nolint:gosec
+		InsecureSkipVerify: c.insecure,
+		ServerName:         c.oidcTLSServerName,
+	}
+
+	return oidc.ClientContext(ctx, &http.Client{Transport: transport})
+}
+
+func refreshAndCacheNewToken(ctx context.Context, providers *oidcProviderCache,
+	clientID, clientSecret string, cache cache.Cache[interface{}], tokenType, token, issuerURL string,
 ) (*oauth2.Token, error) {
 	// get provider
-	provider, err := oidc.NewProvider(context.Background(), issuerURL)
+	provider, err := providers.get(ctx, issuerURL, "")
 	if err != nil {
 		return nil, fmt.Errorf("getting provider: %v", err)
 	}
 	// get refresh token
-	newToken, err := getNewToken(clientID, clientSecret, cache, tokenType, token, provider.Endpoint().TokenURL)
+	newToken, err := getNewToken(ctx, clientID, clientSecret, cache, tokenType, token, provider.Endpoint().TokenURL)
 	if err != nil {
 		return nil, fmt.Errorf("refreshing token: %v", err)
 	}
@@ -896,7 +1239,7 @@ func refreshAndCacheNewToken(clientID, clientSecret string, cache cache.Cache[in
 // getNewToken uses the provided credentials and fetches the old refresh
 // token from the cache to obtain a new OAuth2 token
 // from the specified token URL endpoint.
-func getNewToken(clientID, clientSecret string, cache cache.Cache[interface{}],
+func getNewToken(ctx context.Context, clientID, clientSecret string, cache cache.Cache[interface{}],
 	tokenType string, token string, tokenURL string,
 ) (*oauth2.Token, error) {
 	// get refresh token
@@ -920,7 +1263,7 @@ func getNewToken(clientID, clientSecret string, cache cache.Cache[interface{}],
 	}
 
 	// Request new token using the refresh token
-	newToken, err := conf.TokenSource(context.Background(), &oauth2.Token{
+	newToken, err := conf.TokenSource(ctx, &oauth2.Token{
 		RefreshToken: rToken,
 	}).Token()
 	if err != nil {
@@ -935,13 +1278,25 @@ func getNewToken(clientID, clientSecret string, cache cache.Cache[interface{}],
 	return newToken, nil
 }
 
+// refreshTokenTTL returns how long to keep the refresh token of a token
+// expiring at expiry: oidcRefreshTokenTTL past its expiry, so the token can
+// still be refreshed when an idle user comes back.
+func refreshTokenTTL(expiry time.Time) time.Duration {
+	if expiry.IsZero() {
+		return oidcRefreshTokenTTL
+	}
+
+	return time.Until(expiry) + oidcRefreshTokenTTL
+}
+
 // cacheRefreshedToken updates the refresh token in the cache.
 func cacheRefreshedToken(token *oauth2.Token, tokenType string, oldToken string,
 	oldRefreshToken string, cache cache.Cache[interface{}],
 ) error {
 	newToken, ok := token.Extra(tokenType).(string)
 	if ok {
-		if err := cache.Set(context.Background(), fmt.Sprintf("oidc-token-%s", newToken), token.RefreshToken); err != nil {
+		if err := cache.SetWithTTL(context.Background(), fmt.Sprintf("oidc-token-%s", newToken),
+			token.RefreshToken, refreshTokenTTL(token.Expiry)); err != nil {
 			logger.Log(logger.LevelError, nil, err, "failed to cache refreshed token")
 			return err
 		}
@@ -968,6 +1323,8 @@ func (c *HeadlampConfig) refreshAndSetToken(oidcAuthConfig *kubeconfig.OidcConfi
 	}
 
 	newToken, err := refreshAndCacheNewToken(
+		c.oidcClientContext(context.Background()),
+		c.oidcProviders,
 		oidcAuthConfig.ClientID,
 		oidcAuthConfig.ClientSecret,
 		cache,
@@ -981,6 +1338,19 @@ func (c *HeadlampConfig) refreshAndSetToken(oidcAuthConfig *kubeconfig.OidcConfi
 		c.telemetryHandler.RecordError(span, err, "Token refresh failed")
 		c.telemetryHandler.RecordErrorCount(ctx, attribute.String("error", "token_refresh_failure"))
 	} else if newToken != nil {
+		newRawToken, _ := newToken.Extra(tokenType).(string)
+		if err := c.checkOIDCTokenSize(newRawToken); err != nil {
+			logger.Log(logger.LevelError, map[string]string{"cluster": cluster},
+				err, "rejecting refreshed token")
+			c.telemetryHandler.RecordError(span, err, "Token refresh failed")
+
+			return
+		}
+
+		if newRawToken != "" {
+			c.carryOverOIDCSession(token, newRawToken)
+		}
+
 		if c.oidcUseAccessToken {
 			w.Header().Set("X-Authorization", newToken.Extra("access_token").(string))
 		} else {
@@ -991,6 +1361,110 @@ func (c *HeadlampConfig) refreshAndSetToken(oidcAuthConfig *kubeconfig.OidcConfi
 	}
 }
 
+// checkOIDCTokenSize returns an error if token is larger than
+// oidcMaxTokenSize. A zero oidcMaxTokenSize accepts any size.
+func (c *HeadlampConfig) checkOIDCTokenSize(token string) error {
+	if c.oidcMaxTokenSize <= 0 || cfg.ByteSize(len(token)) <= c.oidcMaxTokenSize {
+		return nil
+	}
+
+	return fmt.Errorf("OIDC token of %d bytes exceeds oidc-max-token-size of %s; "+
+		"reduce the claims issued by the identity provider, e.g. groups, or raise the limit",
+		len(token), c.oidcMaxTokenSize)
+}
+
+// sessionFileName is the name of the session file in the session-store-dir.
+const sessionFileName = "headlamp-sessions"
+
+// newSessionCache returns the cache of login sessions for the session-store:
+// a file in sessionStoreDir if it is file, else an in-memory cache.
+func newSessionCache(sessionStore, sessionStoreDir string) (cache.Cache[interface{}], error) {
+	if sessionStore != cfg.SessionStoreFile {
+		return cache.New[interface{}](), nil
+	}
+
+	if err := os.MkdirAll(sessionStoreDir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating session store dir: %w", err)
+	}
+
+	// OIDC session deadlines are stored as time.Time.
+	gob.Register(time.Time{})
+
+	return cache.NewFile[interface{}](filepath.Join(sessionStoreDir, sessionFileName))
+}
+
+// sessions returns the cache holding the OIDC refresh tokens and session
+// deadlines, which is the general cache if no session cache is set.
+func (c *HeadlampConfig) sessions() cache.Cache[interface{}] {
+	if c.sessionCache != nil {
+		return c.sessionCache
+	}
+
+	return c.cache
+}
+
+// oidcSessionKey returns the cache key of the deadline of the OIDC session
+// a token belongs to.
+func oidcSessionKey(token string) string {
+	return fmt.Sprintf("oidc-session-%s", token)
+}
+
+// startOIDCSession records the deadline of the session started by logging in
+// with token, if oidcSessionTTL is set.
+func (c *HeadlampConfig) startOIDCSession(token string) error {
+	if c.oidcSessionTTL <= 0 {
+		return nil
+	}
+
+	return c.sessions().SetWithTTL(context.Background(), oidcSessionKey(token), time.Now().Add(c.oidcSessionTTL),
+		c.oidcSessionTTL)
+}
+
+// carryOverOIDCSession keeps the session deadline of oldToken for the token
+// it was refreshed into, so refreshing does not extend the session.
+func (c *HeadlampConfig) carryOverOIDCSession(oldToken, newToken string) {
+	deadline, err := c.sessions().Get(context.Background(), oidcSessionKey(oldToken))
+	if err != nil {
+		return
+	}
+
+	deadlineTime, ok := deadline.(time.Time)
+	if !ok {
+		return
+	}
+
+	if err := c.sessions().SetWithTTL(context.Background(), oidcSessionKey(newToken), deadlineTime,
+		time.Until(deadlineTime)); err != nil {
+		logger.Log(logger.LevelError, nil, err, "failed to cache OIDC session")
+	}
+}
+
+// isOIDCSessionExpired returns true if token belongs to a session older than
+// oidcSessionTTL. When oidcSessionTTL is set, tokens without a session, i.e.
+// not obtained through the OIDC login, count as expired too.
+func (c *HeadlampConfig) isOIDCSessionExpired(token string) bool {
+	if c.oidcSessionTTL <= 0 {
+		return false
+	}
+
+	value, err := c.sessions().Get(context.Background(), oidcSessionKey(token))
+	if err != nil {
+		return true
+	}
+
+	deadline, ok := value.(time.Time)
+
+	return !ok || time.Now().After(deadline)
+}
+
+// endOIDCSession forgets the refresh token of an expired session. Its token
+// stays rejected, as the session entry is expired or missing.
+func (c *HeadlampConfig) endOIDCSession(token string) {
+	if err := c.sessions().Delete(context.Background(), fmt.Sprintf("oidc-token-%s", token)); err != nil {
+		logger.Log(logger.LevelError, nil, err, "failed to delete OIDC session")
+	}
+}
+
 func (c *HeadlampConfig) incrementRequestCounter(ctx context.Context) {
 	if c.metrics != nil {
 		c.metrics.RequestCounter.Add(ctx, 1,
@@ -1107,6 +1581,18 @@ func (c *HeadlampConfig) OIDCTokenRefreshMiddleware(next http.Handler) http.Hand
 			return
 		}
 
+		// reject tokens of sessions older than oidcSessionTTL, even if still valid
+		if c.isOIDCSessionExpired(token) {
+			c.endOIDCSession(token)
+			c.telemetryHandler.RecordEvent(span, "OIDC session expired")
+			http.Error(w, "OIDC session expired", http.StatusUnauthorized)
+			c.telemetryHandler.RecordDuration(ctx, start,
+				attribute.String("api.route", "OIDCTokenRefreshMiddleware"),
+				attribute.String("status", "session_expired"))
+
+			return
+		}
+
 		// skip if token is not about to expire
 		if !isTokenAboutToExpire(token) {
 			c.telemetryHandler.RecordEvent(span, "Token not about to expire, skipping refresh")
@@ -1119,7 +1605,7 @@ func (c *HeadlampConfig) OIDCTokenRefreshMiddleware(next http.Handler) http.Hand
 		}
 
 		// refresh and cache new token
-		c.refreshAndSetToken(oidcAuthConfig, c.cache, token, w, cluster, span, ctx)
+		c.refreshAndSetToken(oidcAuthConfig, c.sessions(), token, w, cluster, span, ctx)
 
 		next.ServeHTTP(w, r)
 		c.telemetryHandler.RecordDuration(ctx, start,
@@ -1128,7 +1614,79 @@ func (c *HeadlampConfig) OIDCTokenRefreshMiddleware(next http.Handler) http.Hand
 	})
 }
 
+// startupWatchdog bounds the server initialization with a context deadline.
+// If the deadline is reached before Done is called, it logs the step that was
+// still pending and exits with a non-zero code.
+type startupWatchdog struct {
+	mu     sync.Mutex
+	step   string
+	cancel context.CancelFunc
+	exit   func(code int)
+}
+
+// newStartupWatchdog starts a watchdog for the given timeout, calling exit when
+// it is reached; 0 means no bound.
+func newStartupWatchdog(timeout time.Duration, exit func(code int)) *startupWatchdog {
+	w := &startupWatchdog{exit: exit}
+
+	if timeout <= 0 {
+		w.cancel = func() {}
+
+		return w
+	}
+
+	var ctx context.Context
+
+	ctx, w.cancel = context.WithTimeout(context.Background(), timeout)
+
+	go func() {
+		<-ctx.Done()
+
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return
+		}
+
+		w.mu.Lock()
+		step := w.step
+		w.mu.Unlock()
+
+		logger.Log(logger.LevelError, map[string]string{"step": step, "timeout": timeout.String()},
+			ctx.Err(), "startup did not finish in time")
+		w.exit(1)
+	}()
+
+	return w
+}
+
+// Step records the initialization step that is about to run.
+func (w *startupWatchdog) Step(step string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.step = step
+}
+
+// Done stops the watchdog once initialization has finished.
+func (w *startupWatchdog) Done() {
+	w.cancel()
+}
+
 func StartHeadlampServer(config *HeadlampConfig) {
+	exit := config.exit
+	if exit == nil {
+		exit = os.Exit
+	}
+
+	// main starts the watchdog, so it also covers the kubeconfig and session store.
+	watchdog := config.startupWatchdog
+	if watchdog == nil {
+		watchdog = newStartupWatchdog(0, exit)
+	}
+
+	defer watchdog.Done()
+
+	watchdog.Step("telemetry")
+
 	tel, err := telemetry.NewTelemetry(config.telemetryConfig)
 	if err != nil {
 		logger.Log(logger.LevelError, nil, err, "Failed to initialize telemetry")
@@ -1144,25 +1702,30 @@ func StartHeadlampServer(config *HeadlampConfig) {
 		}
 	}()
 
+	watchdog.Step("metrics")
+
 	metrics, err := telemetry.NewMetrics()
 	if err != nil {
 		logger.Log(logger.LevelError, nil, err, "Failed to initialize metrics")
 	}
 
+	if metrics != nil {
+		metrics.ExcludePaths = config.telemetryConfig.MetricsExcludePathList()
+
+		if !config.telemetryConfig.TelemetryExcludeClusterLabel {
+			metrics.ClusterContext = config.clusterContextName
+		}
+	}
+
 	config.telemetry = tel
 	config.metrics = metrics
 	config.telemetryHandler = telemetry.NewRequestHandler(tel, metrics)
 
-	router := mux.NewRouter()
-
-	if config.telemetry != nil && config.metrics != nil {
-		router.Use(telemetry.TracingMiddleware("headlamp-server"))
-		router.Use(config.metrics.RequestCounterMiddleware)
-	}
-
 	// Copy static files as squashFS is read-only (AppImage)
 	if config.staticDir != "" {
-		dir, err := os.MkdirTemp(os.TempDir(), ".headlamp")
+		watchdog.Step("copying static files")
+
+		dir, err := os.MkdirTemp(config.tempDir, ".headlamp")
 		if err != nil {
 			logger.Log(logger.LevelError, nil, err, "Failed to create static dir")
 			return
@@ -1177,17 +1740,104 @@ func StartHeadlampServer(config *HeadlampConfig) {
 		config.staticDir = dir
 	}
 
+	watchdog.Step("loading kubeconfigs, plugins and routes")
+
 	handler := createHeadlampHandler(config)
 
 	handler = config.OIDCTokenRefreshMiddleware(handler)
 
+	if config.perUserRateLimit > 0 {
+		limiter := newRateLimiter(config.perUserRateLimit, config.perUserRateBurst,
+			config.rateLimitUser, config.isRateLimitExempt)
+		limiter.trustedProxies = config.perUserRateTrustedProxies
+		handler = limiter.middleware(handler)
+	}
+
+	if config.globalRequestTimeout > 0 {
+		handler = newRequestTimeoutHandler(config.globalRequestTimeout, handler)
+	}
+
+	if config.accessLog {
+		handler = newAccessLogHandler(config.accessLogFormat, config.logRedactParams, handler)
+	}
+
 	addr := fmt.Sprintf("%s:%d", config.listenAddr, config.port)
 
+	watchdog.Done()
+
+	server := &http.Server{Addr: addr, Handler: handler} //nolint:gosec
+	hijacked := newHijackedConns()
+	shutdownDone := make(chan struct{})
+
+	go func() {
+		defer close(shutdownDone)
+
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+		<-stop
+
+		config.shutdownServer(server, hijacked)
+	}()
+
 	// Start server
-	if err := http.ListenAndServe(addr, handler); err != nil { //nolint:gosec
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		logger.Log(logger.LevelError, nil, err, "Failed to start server")
+		return
+	}
+
+	if err := server.Serve(hijacked.track(server, listener)); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		logger.Log(logger.LevelError, nil, err, "Failed to start server")
 		return
 	}
+
+	<-shutdownDone
+}
+
+// clusterContextName returns the name of the cluster context a request
+// targets, for telemetry. Names not in the context store are left out, so
+// requests to made up clusters don't add labels.
+func (c *HeadlampConfig) clusterContextName(r *http.Request) string {
+	name := mux.Vars(r)["clusterName"]
+	if name == "" {
+		return ""
+	}
+
+	if _, err := c.kubeConfigStore.GetContext(name); err != nil {
+		return ""
+	}
+
+	return name
+}
+
+// shutdownServer stops accepting requests and lets the open ones, like watch
+// streams and the hijacked connections of WebSockets, continue for up to
+// watchDrainTimeout before closing them.
+func (c *HeadlampConfig) shutdownServer(server *http.Server, hijacked *hijackedConns) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.watchDrainTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Log(logger.LevelWarn, nil, err, "closing requests still open after watch-drain-timeout")
+
+		if err := server.Close(); err != nil {
+			logger.Log(logger.LevelError, nil, err, "failed to close server")
+		}
+	}
+
+	// The server does not track hijacked connections, so they are waited for
+	// separately, with what is left of watchDrainTimeout.
+	if err := hijacked.wait(ctx); err != nil {
+		logger.Log(logger.LevelWarn, map[string]string{"connections": strconv.Itoa(hijacked.len())},
+			err, "closing websockets still open after watch-drain-timeout")
+		hijacked.closeAll()
+	}
+
+	// The connections of the multiplexer to the clusters end with the
+	// websockets of the clients.
+	if c.multiplexer != nil {
+		c.multiplexer.cleanupConnections()
+	}
 }
 
 // Returns the helm.Handler given the config and request. Writes http.NotFound if clusterName is not there.
@@ -1413,8 +2063,16 @@ func handleClusterAPI(c *HeadlampConfig, router *mux.Router) { //nolint:funlen
 
 		// Process WebSocket protocol headers if present
 		processWebSocketProtocolHeader(r)
+
+		for h, val := range c.proxyAddRequestHeaders {
+			r.Header.Set(h, val)
+		}
 		plugins.HandlePluginReload(c.cache, w)
 
+		if len(c.proxyStripResponseHeaders) > 0 {
+			w = &headerStrippingWriter{ResponseWriter: w, headers: c.proxyStripResponseHeaders}
+		}
+
 		if err = kContext.ProxyRequest(w, r); err != nil {
 			c.telemetryHandler.RecordErrorCount(ctx, attribute.String("error.type", "proxy_error"),
 				attribute.String("cluster", contextKey))
@@ -1430,6 +2088,43 @@ func handleClusterAPI(c *HeadlampConfig, router *mux.Router) { //nolint:funlen
 	})
 }
 
+// headerStrippingWriter removes headers from a response before they are sent.
+// The headers of 101 Switching Protocols responses are kept, as upgrades need
+// them.
+type headerStrippingWriter struct {
+	http.ResponseWriter
+	headers     []string
+	wroteHeader bool
+}
+
+func (w *headerStrippingWriter) WriteHeader(code int) {
+	if code != http.StatusSwitchingProtocols {
+		for _, header := range w.headers {
+			w.Header().Del(header)
+		}
+	}
+
+	if code >= http.StatusOK || code == http.StatusSwitchingProtocols {
+		w.wroteHeader = true
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *headerStrippingWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// flush watch responses or hijack upgraded connections.
+func (w *headerStrippingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 func recordRequestCompletion(c *HeadlampConfig, ctx context.Context,
 	start time.Time, r *http.Request,
 ) {
@@ -1668,7 +2363,7 @@ func parseClusterFromKubeConfig(kubeConfigs []string) ([]Cluster, []error) {
 func (c *HeadlampConfig) getConfig(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	clientConfig := clientConfig{c.getClusters(), c.enableDynamicClusters}
+	clientConfig := clientConfig{c.getClusters(), c.enableDynamicClusters, c.listChunkSize, c.defaultCluster}
 
 	if err := json.NewEncoder(w).Encode(&clientConfig); err != nil {
 		logger.Log(logger.LevelError, nil, err, "encoding config")
@@ -2341,8 +3036,7 @@ func (c *HeadlampConfig) drainNode(clientset *kubernetes.Clientset, nodeName str
 			return
 		}
 
-		pods, err := clientset.CoreV1().Pods("").List(context.TODO(),
-			v1.ListOptions{FieldSelector: "spec.nodeName=" + nodeName})
+		pods, err := c.listNodePods(context.TODO(), clientset, nodeName)
 		if err != nil {
 			_ = c.cache.SetWithTTL(ctx, cacheKey, "error: "+err.Error(), cacheItemTTL)
 			return
@@ -2350,7 +3044,7 @@ func (c *HeadlampConfig) drainNode(clientset *kubernetes.Clientset, nodeName str
 
 		var gracePeriod int64 = 0
 
-		for _, pod := range pods.Items {
+		for _, pod := range pods {
 			// ignore daemonsets
 			if pod.ObjectMeta.Labels["kubernetes.io/created-by"] == "daemonset-controller" {
 				continue
@@ -2364,6 +3058,33 @@ func (c *HeadlampConfig) drainNode(clientset *kubernetes.Clientset, nodeName str
 	}()
 }
 
+// listNodePods lists the pods running on nodeName, requesting them in pages of
+// listChunkSize items, so nodes with many pods don't need one huge response.
+func (c *HeadlampConfig) listNodePods(ctx context.Context, clientset kubernetes.Interface,
+	nodeName string,
+) ([]corev1.Pod, error) {
+	listPager := pager.New(func(ctx context.Context, opts v1.ListOptions) (k8sruntime.Object, error) {
+		return clientset.CoreV1().Pods("").List(ctx, opts)
+	})
+	listPager.PageSize = int64(c.listChunkSize)
+
+	var pods []corev1.Pod
+
+	err := listPager.EachListItem(ctx, v1.ListOptions{FieldSelector: "spec.nodeName=" + nodeName},
+		func(obj k8sruntime.Object) error {
+			pod, ok := obj.(*corev1.Pod)
+			if !ok {
+				return fmt.Errorf("unexpected object %T in pod list", obj)
+			}
+
+			pods = append(pods, *pod)
+
+			return nil
+		})
+
+	return pods, err
+}
+
 /*
 * Handle node drain status
 Since node drain is an async operation, we need to poll for the status of the drain operation
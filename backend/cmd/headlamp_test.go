@@ -22,7 +22,10 @@ import (
 	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -32,14 +35,27 @@ import (
 	"testing"
 	"time"
 
+	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
 	"github.com/kubernetes-sigs/headlamp/backend/pkg/cache"
 	"github.com/kubernetes-sigs/headlamp/backend/pkg/config"
 	"github.com/kubernetes-sigs/headlamp/backend/pkg/kubeconfig"
 	"github.com/kubernetes-sigs/headlamp/backend/pkg/telemetry"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"golang.org/x/oauth2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/clientcmd/api"
 )
@@ -119,6 +135,37 @@ func TestSpaHandlerOtherFiles(t *testing.T) {
 	}
 }
 
+func TestSpaHandlerCacheControl(t *testing.T) {
+	handler := spaHandler{
+		staticPath:   staticTestPath,
+		indexPath:    "index.html",
+		baseURL:      "/headlamp",
+		cacheControl: "public, max-age=3600",
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/headlamp/example.css", want: "public, max-age=3600"},
+		{path: "/headlamp/", want: "no-cache"},
+		{path: "/headlamp/index.html", want: "no-cache"},
+		{path: "/headlamp/some/route", want: "no-cache"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(context.Background(), "GET", tt.path, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.want, rr.Header().Get("Cache-Control"))
+		})
+	}
+}
+
 func makeJSONReq(method, url string, jsonObj interface{}) (*http.Request, error) {
 	var jsonBytes []byte = nil
 
@@ -504,6 +551,58 @@ func TestExternalProxy(t *testing.T) {
 	}
 }
 
+func TestHeaderStrippingWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := &headerStrippingWriter{ResponseWriter: rec, headers: []string{"Server", "Upgrade"}}
+
+	w.Header().Set("Server", "kube-apiserver")
+	w.Header().Set("Upgrade", "websocket")
+	w.Header().Set("Content-Type", "application/json")
+
+	_, err := w.Write([]byte("{}"))
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Server"))
+	assert.Empty(t, rec.Header().Get("Upgrade"))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	// Upgrade responses keep their headers.
+	rec = httptest.NewRecorder()
+	w = &headerStrippingWriter{ResponseWriter: rec, headers: []string{"Upgrade"}}
+
+	w.Header().Set("Upgrade", "websocket")
+	w.WriteHeader(http.StatusSwitchingProtocols)
+
+	assert.Equal(t, "websocket", rec.Header().Get("Upgrade"))
+}
+
+func TestProxyURLsFile(t *testing.T) {
+	proxyURLsFile := filepath.Join(t.TempDir(), "proxy-urls")
+	require.NoError(t, os.WriteFile(proxyURLsFile, []byte("https://example.com/*\n"), 0o600))
+
+	c := &HeadlampConfig{
+		proxyURLs:     []string{"https://artifacthub.io/*"},
+		proxyURLsFile: proxyURLsFile,
+	}
+
+	assert.True(t, c.isProxyURLAllowed("https://artifacthub.io/api/v1"))
+	assert.False(t, c.isProxyURLAllowed("https://example.com/api"))
+
+	require.NoError(t, c.loadProxyURLsFile())
+
+	assert.True(t, c.isProxyURLAllowed("https://artifacthub.io/api/v1"))
+	assert.True(t, c.isProxyURLAllowed("https://example.com/api"))
+	assert.False(t, c.isProxyURLAllowed("https://example.org/api"))
+
+	// An invalid file keeps the previously loaded entries.
+	require.NoError(t, os.WriteFile(proxyURLsFile, []byte("example.org\n"), 0o600))
+	require.Error(t, c.loadProxyURLsFile())
+
+	assert.True(t, c.isProxyURLAllowed("https://example.com/api"))
+	assert.False(t, c.isProxyURLAllowed("https://example.org/api"))
+}
+
 func TestDrainAndCordonNode(t *testing.T) { //nolint:funlen
 	type test struct {
 		handler http.Handler
@@ -569,6 +668,41 @@ func TestDrainAndCordonNode(t *testing.T) { //nolint:funlen
 	}
 }
 
+func TestListNodePods(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+
+	var limits []int64
+
+	clientset.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		opts := action.(k8stesting.ListActionImpl).ListOptions
+		limits = append(limits, opts.Limit)
+
+		assert.Equal(t, "spec.nodeName=node-1", opts.FieldSelector)
+
+		if opts.Continue == "" {
+			return true, &corev1.PodList{
+				ListMeta: metav1.ListMeta{Continue: "page-2"},
+				Items:    []corev1.Pod{{ObjectMeta: metav1.ObjectMeta{Name: "a"}}, {ObjectMeta: metav1.ObjectMeta{Name: "b"}}},
+			}, nil
+		}
+
+		return true, &corev1.PodList{Items: []corev1.Pod{{ObjectMeta: metav1.ObjectMeta{Name: "c"}}}}, nil
+	})
+
+	config := &HeadlampConfig{listChunkSize: 2}
+
+	pods, err := config.listNodePods(context.Background(), clientset, "node-1")
+	require.NoError(t, err)
+
+	names := []string{}
+	for _, pod := range pods {
+		names = append(names, pod.Name)
+	}
+
+	assert.Equal(t, []string{"a", "b", "c"}, names)
+	assert.Equal(t, []int64{2, 2}, limits)
+}
+
 func TestDeletePlugin(t *testing.T) {
 	// create temp dir for plugins
 	tempDir, err := os.MkdirTemp("", "plugins")
@@ -687,6 +821,164 @@ func handleClusterRenameRequest(
 }
 
 // TestCheckUniqueName checks the CheckUniqueName function which checks if a new name is unique among existing contexts.
+// setupTestMeter makes NewMetrics record to the returned reader.
+func setupTestMeter(t *testing.T) *sdkmetric.ManualReader {
+	t.Helper()
+
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	originalProvider := otel.GetMeterProvider()
+	otel.SetMeterProvider(provider)
+
+	t.Cleanup(func() {
+		otel.SetMeterProvider(originalProvider)
+		_ = provider.Shutdown(context.Background())
+	})
+
+	return reader
+}
+
+// requestCounts returns the http.server.request_count data points collected
+// by reader, keyed by their http.target.
+func requestCounts(t *testing.T, reader *sdkmetric.ManualReader) map[string]metricdata.DataPoint[int64] {
+	t.Helper()
+
+	var data metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &data))
+
+	counts := map[string]metricdata.DataPoint[int64]{}
+
+	for _, scopeMetric := range data.ScopeMetrics {
+		for _, m := range scopeMetric.Metrics {
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if m.Name != "http.server.request_count" || !ok {
+				continue
+			}
+
+			for _, dp := range sum.DataPoints {
+				target, _ := dp.Attributes.Value("http.target")
+				counts[target.AsString()] = dp
+			}
+		}
+	}
+
+	return counts
+}
+
+func TestHeadlampHandlerMetricsExcludePaths(t *testing.T) {
+	reader := setupTestMeter(t)
+
+	clusterServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer clusterServer.Close()
+
+	kubeConfigStore := kubeconfig.NewContextStore()
+	require.NoError(t, kubeConfigStore.AddContext(&kubeconfig.Context{
+		Name:    "test",
+		Cluster: &api.Cluster{Server: clusterServer.URL},
+	}))
+
+	metrics, err := telemetry.NewMetrics()
+	require.NoError(t, err)
+
+	telemetryConfig := GetDefaultTestTelemetryConfig()
+	telemetryConfig.MetricsExcludePaths = "/clusters/*"
+	metrics.ExcludePaths = telemetryConfig.MetricsExcludePathList()
+
+	handler := createHeadlampHandler(&HeadlampConfig{
+		kubeConfigPath:   config.GetDefaultKubeConfigPath(),
+		cache:            cache.New[interface{}](),
+		kubeConfigStore:  kubeConfigStore,
+		telemetry:        &telemetry.Telemetry{},
+		metrics:          metrics,
+		telemetryConfig:  telemetryConfig,
+		telemetryHandler: telemetry.NewRequestHandler(nil, metrics),
+	})
+
+	for _, path := range []string{"/clusters/test/version", "/config"} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, rr.Code, path)
+	}
+
+	counts := requestCounts(t, reader)
+	assert.Contains(t, counts, "/config")
+	assert.NotContains(t, counts, "/clusters/test/version")
+}
+
+func TestHeadlampHandlerClusterContext(t *testing.T) {
+	reader := setupTestMeter(t)
+
+	spans := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	originalTracerProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(tracerProvider)
+
+	t.Cleanup(func() {
+		otel.SetTracerProvider(originalTracerProvider)
+		_ = tracerProvider.Shutdown(context.Background())
+	})
+
+	clusterServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer clusterServer.Close()
+
+	kubeConfigStore := kubeconfig.NewContextStore()
+	require.NoError(t, kubeConfigStore.AddContext(&kubeconfig.Context{
+		Name:    "test",
+		Cluster: &api.Cluster{Server: clusterServer.URL},
+	}))
+
+	metrics, err := telemetry.NewMetrics()
+	require.NoError(t, err)
+
+	c := &HeadlampConfig{
+		kubeConfigPath:   config.GetDefaultKubeConfigPath(),
+		cache:            cache.New[interface{}](),
+		kubeConfigStore:  kubeConfigStore,
+		telemetry:        &telemetry.Telemetry{},
+		metrics:          metrics,
+		telemetryConfig:  GetDefaultTestTelemetryConfig(),
+		telemetryHandler: telemetry.NewRequestHandler(nil, metrics),
+	}
+	metrics.ClusterContext = c.clusterContextName
+
+	handler := createHeadlampHandler(c)
+
+	for _, path := range []string{"/clusters/test/version", "/config"} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, rr.Code, path)
+	}
+
+	counts := requestCounts(t, reader)
+	require.Contains(t, counts, "/clusters/test/version")
+	require.Contains(t, counts, "/config")
+
+	clusterAttrs := counts["/clusters/test/version"].Attributes
+	cluster, _ := clusterAttrs.Value(telemetry.ClusterContextKey)
+	assert.Equal(t, "test", cluster.AsString())
+
+	configAttrs := counts["/config"].Attributes
+	_, ok := configAttrs.Value(telemetry.ClusterContextKey)
+	assert.False(t, ok)
+
+	spanClusters := []string{}
+
+	for _, span := range spans.Ended() {
+		for _, attr := range span.Attributes() {
+			if attr.Key == telemetry.ClusterContextKey {
+				spanClusters = append(spanClusters, attr.Value.AsString())
+			}
+		}
+	}
+
+	assert.Equal(t, []string{"test"}, spanClusters)
+}
+
 func TestCheckUniqueName(t *testing.T) {
 	// Need the parsed *api.Config so we can reference the contexts
 	kubeConfig, err := clientcmd.LoadFromFile("./headlamp_testdata/name_validation_test")
@@ -1031,6 +1323,347 @@ func TestOIDCTokenRefreshMiddleware(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rec.Code)
 }
 
+func TestOIDCSessionTTL(t *testing.T) {
+	config := &HeadlampConfig{
+		cache:          cache.New[interface{}](),
+		oidcSessionTTL: time.Hour,
+	}
+
+	require.NoError(t, config.startOIDCSession("token-a"))
+	assert.False(t, config.isOIDCSessionExpired("token-a"))
+
+	// Tokens that did not come from the OIDC login have no session.
+	assert.True(t, config.isOIDCSessionExpired("unknown-token"))
+
+	// Refreshed tokens keep the deadline of the session they belong to.
+	config.carryOverOIDCSession("token-a", "token-c")
+	assert.False(t, config.isOIDCSessionExpired("token-c"))
+
+	require.NoError(t, config.cache.Set(context.Background(), oidcSessionKey("token-a"),
+		time.Now().Add(-time.Minute)))
+	config.carryOverOIDCSession("token-a", "token-b")
+	assert.True(t, config.isOIDCSessionExpired("token-a"))
+	assert.True(t, config.isOIDCSessionExpired("token-b"))
+
+	// Ended sessions stay expired, but their refresh token is dropped.
+	require.NoError(t, config.cache.Set(context.Background(), "oidc-token-token-b", "refresh-token"))
+	config.endOIDCSession("token-b")
+	assert.True(t, config.isOIDCSessionExpired("token-b"))
+
+	_, err := config.cache.Get(context.Background(), "oidc-token-token-b")
+	assert.Error(t, err)
+
+	// Session entries are removed from the store once their session is over.
+	config.oidcSessionTTL = time.Millisecond
+	require.NoError(t, config.startOIDCSession("token-d"))
+	time.Sleep(5 * time.Millisecond)
+
+	_, err = config.cache.Get(context.Background(), oidcSessionKey("token-d"))
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+
+	config.oidcSessionTTL = 0
+	assert.False(t, config.isOIDCSessionExpired("token-a"))
+	assert.False(t, config.isOIDCSessionExpired("unknown-token"))
+}
+
+func TestRefreshTokenTTL(t *testing.T) {
+	assert.Equal(t, oidcRefreshTokenTTL, refreshTokenTTL(time.Time{}))
+
+	ttl := refreshTokenTTL(time.Now().Add(time.Hour))
+	assert.InDelta(t, (time.Hour + oidcRefreshTokenTTL).Seconds(), ttl.Seconds(), 1)
+}
+
+func TestNewSessionCache(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sessions", "headlamp")
+
+	sessions, err := newSessionCache(config.SessionStoreFile, dir)
+	require.NoError(t, err)
+
+	require.NoError(t, sessions.Set(context.Background(), "oidc-token-a", "refresh-token"))
+	assert.FileExists(t, filepath.Join(dir, sessionFileName))
+}
+
+func TestCheckOIDCTokenSize(t *testing.T) {
+	config := &HeadlampConfig{}
+	assert.NoError(t, config.checkOIDCTokenSize(strings.Repeat("a", 1<<20)))
+
+	config.oidcMaxTokenSize = 16
+	assert.NoError(t, config.checkOIDCTokenSize(strings.Repeat("a", 16)))
+
+	err := config.checkOIDCTokenSize(strings.Repeat("a", 17))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "17 bytes")
+	assert.Contains(t, err.Error(), "oidc-max-token-size of 16B")
+}
+
+//nolint:funlen
+func TestWithUserInfoClaims(t *testing.T) {
+	userInfo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer access-token", r.Header.Get("Authorization"))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"sub":"user","email":"user@example.com","groups":["admins","devs"]}`))
+	}))
+	defer userInfo.Close()
+
+	oauthConfig := &OauthConfig{
+		Provider: (&oidc.ProviderConfig{UserInfoURL: userInfo.URL}).NewProvider(context.Background()),
+		Ctx:      context.Background(),
+	}
+	token := &oauth2.Token{AccessToken: "access-token", TokenType: "Bearer"}
+	idToken := &oidc.IDToken{Subject: "user"}
+	claims := json.RawMessage(`{"sub":"user","email":"token@example.com","groups":["token-group"]}`)
+
+	claimsOf := func(t *testing.T, raw json.RawMessage) map[string]interface{} {
+		var decoded map[string]interface{}
+		require.NoError(t, json.Unmarshal(raw, &decoded))
+
+		return decoded
+	}
+
+	t.Run("missing_claims", func(t *testing.T) {
+		config := &HeadlampConfig{oidcUserInfoEnabled: true}
+
+		merged, err := config.withUserInfoClaims(oauthConfig, token, idToken, json.RawMessage(`{"sub":"user"}`))
+		require.NoError(t, err)
+
+		decoded := claimsOf(t, merged)
+		assert.Equal(t, "user@example.com", decoded["email"])
+		assert.Equal(t, []interface{}{"admins", "devs"}, decoded["groups"])
+	})
+
+	t.Run("token_claims_first", func(t *testing.T) {
+		config := &HeadlampConfig{oidcUserInfoEnabled: true}
+
+		merged, err := config.withUserInfoClaims(oauthConfig, token, idToken, claims)
+		require.NoError(t, err)
+
+		decoded := claimsOf(t, merged)
+		assert.Equal(t, "token@example.com", decoded["email"])
+		assert.Equal(t, []interface{}{"token-group"}, decoded["groups"])
+	})
+
+	t.Run("groups_from_userinfo", func(t *testing.T) {
+		config := &HeadlampConfig{oidcUserInfoEnabled: true, oidcGroupsFromUserInfo: true}
+
+		merged, err := config.withUserInfoClaims(oauthConfig, token, idToken, claims)
+		require.NoError(t, err)
+
+		decoded := claimsOf(t, merged)
+		assert.Equal(t, "token@example.com", decoded["email"])
+		assert.Equal(t, []interface{}{"admins", "devs"}, decoded["groups"])
+	})
+
+	t.Run("other_subject", func(t *testing.T) {
+		config := &HeadlampConfig{oidcUserInfoEnabled: true, oidcGroupsFromUserInfo: true}
+
+		_, err := config.withUserInfoClaims(oauthConfig, token, &oidc.IDToken{Subject: "other"}, claims)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "does not match")
+	})
+}
+
+func TestIsAllowedRedirect(t *testing.T) {
+	config := &HeadlampConfig{oidcAllowedRedirectHosts: []string{"headlamp.example.com"}}
+	assert.True(t, config.isAllowedRedirect("/auth?cluster=minikube"))
+	assert.True(t, config.isAllowedRedirect("https://HEADLAMP.example.com/oidc-callback"))
+	assert.False(t, config.isAllowedRedirect("https://evil.example.com/"))
+	assert.False(t, config.isAllowedRedirect("//evil.example.com/"))
+	assert.False(t, config.isAllowedRedirect("/\\evil.example.com/"))
+	assert.False(t, config.isAllowedRedirect("http://localhost:3000/"))
+
+	config.devMode = true
+	assert.True(t, config.isAllowedRedirect("http://localhost:3000/"))
+	assert.True(t, config.isAllowedRedirect("https://headlamp.example.com/oidc-callback"))
+	assert.False(t, config.isAllowedRedirect("https://evil.example.com/"))
+
+	// Without configured hosts, any host is allowed.
+	config = &HeadlampConfig{}
+	assert.True(t, config.isAllowedRedirect("/auth?cluster=minikube"))
+	assert.True(t, config.isAllowedRedirect("https://headlamp.example.com/oidc-callback"))
+}
+
+func TestOidcCallbackURLIngressHost(t *testing.T) {
+	// Behind an ingress, the host of the request is the one of the ingress,
+	// not the one Headlamp listens on.
+	r := httptest.NewRequest(http.MethodGet, "/oidc?cluster=minikube", nil)
+	r.Host = "headlamp.example.com"
+	r.Header.Set("X-Forwarded-Proto", "https")
+
+	tests := []struct {
+		name         string
+		allowedHosts []string
+		want         bool
+	}{
+		{name: "no_allowed_hosts", want: true},
+		{name: "allowed_ingress_host", allowedHosts: []string{"localhost", "headlamp.example.com"}, want: true},
+		{name: "other_ingress_host", allowedHosts: []string{"headlamp.other.example.com"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &HeadlampConfig{
+				listenAddr:               "0.0.0.0",
+				oidcAllowedRedirectHosts: tt.allowedHosts,
+			}
+
+			callbackURL := getOidcCallbackURL(r, config)
+			assert.Equal(t, "https://headlamp.example.com/oidc-callback", callbackURL)
+			assert.Equal(t, tt.want, config.isAllowedRedirect(callbackURL))
+		})
+	}
+}
+
+func TestOIDCClientContext(t *testing.T) {
+	config := &HeadlampConfig{}
+	assert.Nil(t, config.oidcClientContext(context.Background()).Value(oauth2.HTTPClient))
+
+	config.oidcTLSServerName = "login.example.com"
+
+	client, ok := config.oidcClientContext(context.Background()).Value(oauth2.HTTPClient).(*http.Client)
+	require.True(t, ok)
+
+	transport, ok := client.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.Equal(t, "login.example.com", transport.TLSClientConfig.ServerName)
+	assert.False(t, transport.TLSClientConfig.InsecureSkipVerify)
+}
+
+func TestShutdownServer(t *testing.T) {
+	watching := make(chan struct{})
+	server := &http.Server{ //nolint:gosec
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			close(watching)
+
+			// Stream until the connection is closed, like a watch.
+			<-r.Context().Done()
+		}),
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	hijacked := newHijackedConns()
+
+	go func() {
+		_ = server.Serve(hijacked.track(server, listener))
+	}()
+
+	resp, err := http.Get("http://" + listener.Addr().String()) //nolint:noctx
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	<-watching
+
+	config := &HeadlampConfig{watchDrainTimeout: 100 * time.Millisecond}
+	start := time.Now()
+
+	config.shutdownServer(server, hijacked)
+
+	assert.GreaterOrEqual(t, time.Since(start), config.watchDrainTimeout)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	// The watch was closed once the drain timeout passed.
+	_, err = io.ReadAll(resp.Body)
+	assert.Error(t, err)
+}
+
+// startWebSocketWatchServer serves a WebSocket streaming events until it is
+// closed, like a watch through the multiplexer, and returns a client of it.
+func startWebSocketWatchServer(t *testing.T) (*http.Server, *hijackedConns, *websocket.Conn) {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{}
+	server := &http.Server{ //nolint:gosec
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+
+			defer conn.Close()
+
+			for {
+				if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"MODIFIED"}`)); err != nil {
+					return
+				}
+
+				time.Sleep(10 * time.Millisecond)
+			}
+		}),
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	hijacked := newHijackedConns()
+
+	go func() {
+		_ = server.Serve(hijacked.track(server, listener))
+	}()
+
+	conn, resp, err := websocket.DefaultDialer.Dial("ws://"+listener.Addr().String(), nil)
+	require.NoError(t, err)
+
+	resp.Body.Close()
+
+	t.Cleanup(func() { conn.Close() })
+
+	_, _, err = conn.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, 1, hijacked.len())
+
+	return server, hijacked, conn
+}
+
+func TestShutdownServerWebSocketWatch(t *testing.T) {
+	t.Run("closed_after_timeout", func(t *testing.T) {
+		server, hijacked, conn := startWebSocketWatchServer(t)
+
+		config := &HeadlampConfig{watchDrainTimeout: 200 * time.Millisecond}
+		start := time.Now()
+
+		config.shutdownServer(server, hijacked)
+
+		assert.GreaterOrEqual(t, time.Since(start), config.watchDrainTimeout)
+		assert.Less(t, time.Since(start), 5*time.Second)
+		assert.Equal(t, 0, hijacked.len())
+
+		// The watch kept streaming during the drain and was closed after it.
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				var netErr net.Error
+				assert.False(t, errors.As(err, &netErr) && netErr.Timeout(), "watch was not closed")
+
+				break
+			}
+		}
+	})
+
+	t.Run("closed_by_client", func(t *testing.T) {
+		server, hijacked, conn := startWebSocketWatchServer(t)
+
+		config := &HeadlampConfig{watchDrainTimeout: 5 * time.Second}
+
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			conn.Close()
+		}()
+
+		start := time.Now()
+
+		config.shutdownServer(server, hijacked)
+
+		assert.Less(t, time.Since(start), config.watchDrainTimeout)
+		assert.Equal(t, 0, hijacked.len())
+	})
+}
+
 func TestStartHeadlampServer(t *testing.T) {
 	// Create a temporary directory for plugins
 	tempDir, err := os.MkdirTemp("", "headlamp-test")
@@ -1087,6 +1720,48 @@ func TestStartHeadlampServer(t *testing.T) {
 }
 
 //nolint:funlen
+func TestStartupWatchdog(t *testing.T) {
+	t.Run("timeout", func(t *testing.T) {
+		exitCodes := make(chan int, 1)
+
+		watchdog := newStartupWatchdog(10*time.Millisecond, func(code int) {
+			exitCodes <- code
+		})
+		watchdog.Step("loading kubeconfigs")
+
+		select {
+		case code := <-exitCodes:
+			assert.Equal(t, 1, code)
+		case <-time.After(time.Second):
+			t.Fatal("expected the watchdog to exit")
+		}
+	})
+
+	t.Run("done_before_timeout", func(t *testing.T) {
+		exitCodes := make(chan int, 1)
+
+		watchdog := newStartupWatchdog(50*time.Millisecond, func(code int) {
+			exitCodes <- code
+		})
+		watchdog.Step("telemetry")
+		watchdog.Done()
+
+		select {
+		case <-exitCodes:
+			t.Fatal("watchdog exited after Done")
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+
+	t.Run("no_bound", func(t *testing.T) {
+		watchdog := newStartupWatchdog(0, func(code int) {
+			t.Fatal("watchdog without timeout should never exit")
+		})
+		watchdog.Step("telemetry")
+		watchdog.Done()
+	})
+}
+
 func TestHandleClusterHelm(t *testing.T) {
 	// Set up test environment
 	os.Setenv("HEADLAMP_BACKEND_TOKEN", "test-token")
@@ -1309,3 +1984,33 @@ func TestProcessTokenProtocol(t *testing.T) {
 		})
 	}
 }
+
+func TestClusterContextName(t *testing.T) {
+	kubeConfigStore := kubeconfig.NewContextStore()
+
+	err := kubeConfigStore.AddContext(&kubeconfig.Context{
+		Name:    "minikube",
+		Cluster: &api.Cluster{Server: "https://127.0.0.1:6443"},
+	})
+	require.NoError(t, err)
+
+	c := HeadlampConfig{kubeConfigStore: kubeConfigStore}
+
+	tests := []struct {
+		name string
+		vars map[string]string
+		want string
+	}{
+		{name: "known", vars: map[string]string{"clusterName": "minikube"}, want: "minikube"},
+		{name: "unknown", vars: map[string]string{"clusterName": "made-up"}, want: ""},
+		{name: "no_cluster", vars: nil, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/", nil), tt.vars)
+
+			assert.Equal(t, tt.want, c.clusterContextName(req))
+		})
+	}
+}
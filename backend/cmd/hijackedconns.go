@@ -0,0 +1,147 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// hijackedConnsPollInterval is how often hijackedConns.wait checks whether
+// the hijacked connections were closed.
+const hijackedConnsPollInterval = 50 * time.Millisecond
+
+// hijackedConns tracks the connections handlers take over from the server,
+// like the WebSockets of exec or of the multiplexer, which
+// http.Server.Shutdown neither waits for nor closes.
+type hijackedConns struct {
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+// newHijackedConns returns an empty hijackedConns.
+func newHijackedConns() *hijackedConns {
+	return &hijackedConns{conns: make(map[net.Conn]struct{})}
+}
+
+// track sets the ConnState hook of server to record the connections once
+// hijacked, and wraps listener so their closing is seen. server has to serve
+// the returned listener.
+func (h *hijackedConns) track(server *http.Server, listener net.Listener) net.Listener {
+	server.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state != http.StateHijacked {
+			return
+		}
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		h.conns[conn] = struct{}{}
+	}
+
+	return &trackedListener{Listener: listener, conns: h}
+}
+
+// remove stops tracking conn, once closed.
+func (h *hijackedConns) remove(conn net.Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.conns, conn)
+}
+
+// len returns the number of hijacked connections still open.
+func (h *hijackedConns) len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.conns)
+}
+
+// wait returns once all hijacked connections are closed, or the error of ctx
+// if it is done before.
+func (h *hijackedConns) wait(ctx context.Context) error {
+	ticker := time.NewTicker(hijackedConnsPollInterval)
+	defer ticker.Stop()
+
+	for h.len() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	return nil
+}
+
+// closeAll closes the hijacked connections still open.
+func (h *hijackedConns) closeAll() {
+	h.mu.Lock()
+	conns := make([]net.Conn, 0, len(h.conns))
+
+	for conn := range h.conns {
+		conns = append(conns, conn)
+	}
+	h.mu.Unlock()
+
+	for _, conn := range conns {
+		_ = conn.Close()
+	}
+}
+
+// trackedListener wraps the connections it accepts in trackedConn.
+type trackedListener struct {
+	net.Listener
+	conns *hijackedConns
+}
+
+// Accept implements net.Listener.
+func (l *trackedListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	return &trackedConn{Conn: conn, conns: l.conns}, nil
+}
+
+// trackedConn stops being tracked by hijackedConns once closed.
+type trackedConn struct {
+	net.Conn
+	conns *hijackedConns
+}
+
+// Close implements net.Conn.
+func (c *trackedConn) Close() error {
+	c.conns.remove(c)
+
+	return c.Conn.Close()
+}
+
+// CloseWrite lets the server close connections gracefully, as it does with
+// the TCP connections wrapped.
+func (c *trackedConn) CloseWrite() error {
+	if closeWriter, ok := c.Conn.(interface{ CloseWrite() error }); ok {
+		return closeWriter.CloseWrite()
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+)
+
+// cachedOIDCProvider is an OIDC provider and when it has to be fetched again.
+type cachedOIDCProvider struct {
+	provider *oidc.Provider
+	expires  time.Time
+}
+
+// oidcProviderCache keeps the OIDC providers, i.e. their discovery document
+// and signing keys, for ttl, so logins and token refreshes don't fetch them
+// from the IdP each time.
+type oidcProviderCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	providers map[string]cachedOIDCProvider
+	now       func() time.Time
+	fetch     func(ctx context.Context, issuerURL string) (*oidc.Provider, error)
+}
+
+// newOIDCProviderCache returns an oidcProviderCache keeping providers for ttl.
+// A ttl of 0 disables caching.
+func newOIDCProviderCache(ttl time.Duration) *oidcProviderCache {
+	return &oidcProviderCache{
+		ttl:       ttl,
+		providers: make(map[string]cachedOIDCProvider),
+		now:       time.Now,
+		fetch:     oidc.NewProvider,
+	}
+}
+
+// get returns the provider of issuerURL, fetching it if it is not cached or
+// has expired. validatorIssuerURL is the issuer set on ctx with
+// oidc.InsecureIssuerURLContext, if any, as it changes the provider returned.
+// A nil cache fetches the provider each time.
+func (p *oidcProviderCache) get(ctx context.Context, issuerURL, validatorIssuerURL string) (*oidc.Provider, error) {
+	if p == nil {
+		return oidc.NewProvider(ctx, issuerURL)
+	}
+
+	if p.ttl <= 0 {
+		return p.fetch(ctx, issuerURL)
+	}
+
+	key := issuerURL + "\n" + validatorIssuerURL
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if cached, ok := p.providers[key]; ok && p.now().Before(cached.expires) {
+		return cached.provider, nil
+	}
+
+	provider, err := p.fetch(ctx, issuerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	p.providers[key] = cachedOIDCProvider{provider: provider, expires: p.now().Add(p.ttl)}
+
+	return provider, nil
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDCProviderCache(t *testing.T) {
+	now := time.Now()
+	fetches := 0
+
+	newCache := func(ttl time.Duration) *oidcProviderCache {
+		providers := newOIDCProviderCache(ttl)
+		providers.now = func() time.Time { return now }
+		providers.fetch = func(ctx context.Context, issuerURL string) (*oidc.Provider, error) {
+			fetches++
+
+			if issuerURL == "https://broken.example.com" {
+				return nil, errors.New("discovery failed")
+			}
+
+			return (&oidc.ProviderConfig{IssuerURL: issuerURL}).NewProvider(ctx), nil
+		}
+
+		return providers
+	}
+
+	t.Run("cached", func(t *testing.T) {
+		fetches = 0
+		providers := newCache(time.Minute)
+
+		first, err := providers.get(context.Background(), "https://idp.example.com", "")
+		require.NoError(t, err)
+
+		second, err := providers.get(context.Background(), "https://idp.example.com", "")
+		require.NoError(t, err)
+
+		assert.Same(t, first, second)
+		assert.Equal(t, 1, fetches)
+
+		// A different validator issuer gets its own provider.
+		_, err = providers.get(context.Background(), "https://idp.example.com", "https://validator.example.com")
+		require.NoError(t, err)
+		assert.Equal(t, 2, fetches)
+
+		now = now.Add(time.Minute)
+
+		third, err := providers.get(context.Background(), "https://idp.example.com", "")
+		require.NoError(t, err)
+
+		assert.NotSame(t, first, third)
+		assert.Equal(t, 3, fetches)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		fetches = 0
+		providers := newCache(0)
+
+		first, err := providers.get(context.Background(), "https://idp.example.com", "")
+		require.NoError(t, err)
+
+		second, err := providers.get(context.Background(), "https://idp.example.com", "")
+		require.NoError(t, err)
+
+		assert.NotSame(t, first, second)
+		assert.Equal(t, 2, fetches)
+	})
+
+	t.Run("errors_not_cached", func(t *testing.T) {
+		fetches = 0
+		providers := newCache(time.Minute)
+
+		_, err := providers.get(context.Background(), "https://broken.example.com", "")
+		require.Error(t, err)
+
+		_, err = providers.get(context.Background(), "https://broken.example.com", "")
+		require.Error(t, err)
+
+		assert.Equal(t, 2, fetches)
+	})
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubernetes-sigs/headlamp/backend/pkg/auth"
+)
+
+// oidcStateCookie binds an OIDC login to the browser that started it, so
+// the callback of a login started elsewhere, e.g. by an attacker logging the
+// user into their own account, is rejected.
+const oidcStateCookie = "headlamp-oidc-state"
+
+// oidcStateTTL is how long a user has to log in at the OIDC provider.
+const oidcStateTTL = 10 * time.Minute
+
+// setOIDCStateCookie sets the cookie of a login with state, whose callback
+// is at callbackURL. The cookie is encrypted with the cookie encryption key,
+// and holds the state and when the login expires. Without a key, logins are
+// not bound to the browser.
+func (c *HeadlampConfig) setOIDCStateCookie(w http.ResponseWriter, callbackURL, state string) error {
+	if c.cookieEncryptionKey == nil {
+		return nil
+	}
+
+	callback, err := url.Parse(callbackURL)
+	if err != nil {
+		return err
+	}
+
+	expires := time.Now().Add(oidcStateTTL)
+
+	value, err := auth.EncryptCookieValue(c.cookieEncryptionKey, strconv.FormatInt(expires.Unix(), 10)+":"+state)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    value,
+		Path:     callback.Path,
+		MaxAge:   int(oidcStateTTL.Seconds()),
+		Secure:   callback.Scheme == "https",
+		HttpOnly: true,
+		// The callback is a redirect from the OIDC provider, a cross-site
+		// navigation that Lax cookies are still sent with.
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return nil
+}
+
+// checkOIDCStateCookie checks that the callback request r comes from the
+// browser that started the login with state, before it expired. The cookie
+// is cleared, as it is only used once.
+func (c *HeadlampConfig) checkOIDCStateCookie(w http.ResponseWriter, r *http.Request, state string) error {
+	if c.cookieEncryptionKey == nil {
+		return nil
+	}
+
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil {
+		return errors.New("no OIDC state cookie, the login was started in another browser")
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Path:     r.URL.Path,
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+
+	value, err := auth.DecryptCookieValue(c.cookieEncryptionKey, cookie.Value)
+	if err != nil {
+		return fmt.Errorf("decrypting OIDC state cookie: %w", err)
+	}
+
+	expires, cookieState, ok := strings.Cut(value, ":")
+	if !ok || cookieState != state {
+		return errors.New("OIDC state cookie does not match the state of the callback")
+	}
+
+	expiresUnix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().After(time.Unix(expiresUnix, 0)) {
+		return errors.New("OIDC state cookie expired")
+	}
+
+	return nil
+}
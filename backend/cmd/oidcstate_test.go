@@ -0,0 +1,123 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubernetes-sigs/headlamp/backend/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDCStateCookie(t *testing.T) {
+	const (
+		callbackURL = "https://headlamp.example.com/headlamp/oidc-callback"
+		state       = "bWluaWt1YmU="
+	)
+
+	config := &HeadlampConfig{cookieEncryptionKey: bytes.Repeat([]byte{1}, 32)}
+
+	login := func(t *testing.T) *http.Cookie {
+		t.Helper()
+
+		w := httptest.NewRecorder()
+		require.NoError(t, config.setOIDCStateCookie(w, callbackURL, state))
+
+		cookies := w.Result().Cookies()
+		require.Len(t, cookies, 1)
+
+		return cookies[0]
+	}
+
+	callback := func(cookie *http.Cookie) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, callbackURL+"?state="+state, nil)
+		if cookie != nil {
+			r.AddCookie(cookie)
+		}
+
+		return r
+	}
+
+	t.Run("attributes", func(t *testing.T) {
+		cookie := login(t)
+
+		assert.Equal(t, oidcStateCookie, cookie.Name)
+		assert.Equal(t, "/headlamp/oidc-callback", cookie.Path)
+		assert.True(t, cookie.Secure)
+		assert.True(t, cookie.HttpOnly)
+		assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+		assert.NotContains(t, cookie.Value, state)
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		require.NoError(t, config.checkOIDCStateCookie(w, callback(login(t)), state))
+
+		// The cookie is cleared once used.
+		cookies := w.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, -1, cookies[0].MaxAge)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		assert.Error(t, config.checkOIDCStateCookie(httptest.NewRecorder(), callback(nil), state))
+	})
+
+	t.Run("other_state", func(t *testing.T) {
+		err := config.checkOIDCStateCookie(httptest.NewRecorder(), callback(login(t)), "b3RoZXI=")
+		assert.Error(t, err)
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		cookie := login(t)
+		cookie.Value = strings.Map(func(r rune) rune {
+			if r == 'A' {
+				return 'B'
+			}
+
+			return 'A'
+		}, cookie.Value)
+
+		assert.Error(t, config.checkOIDCStateCookie(httptest.NewRecorder(), callback(cookie), state))
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		expired := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+
+		value, err := auth.EncryptCookieValue(config.cookieEncryptionKey, expired+":"+state)
+		require.NoError(t, err)
+
+		cookie := &http.Cookie{Name: oidcStateCookie, Value: value}
+		assert.Error(t, config.checkOIDCStateCookie(httptest.NewRecorder(), callback(cookie), state))
+	})
+
+	t.Run("no_key", func(t *testing.T) {
+		unbound := &HeadlampConfig{}
+
+		w := httptest.NewRecorder()
+		require.NoError(t, unbound.setOIDCStateCookie(w, callbackURL, state))
+		assert.Empty(t, w.Result().Cookies())
+		assert.NoError(t, unbound.checkOIDCStateCookie(httptest.NewRecorder(), callback(nil), state))
+	})
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// errPrivateProxyTarget is returned when the external proxy refuses to
+// connect to a private address.
+var errPrivateProxyTarget = errors.New("proxy target resolves to a private address")
+
+// isPrivateProxyTarget returns true if addr is a private, loopback or
+// link-local address that is not in one of the allowed ranges.
+func isPrivateProxyTarget(addr netip.Addr, allowed []netip.Prefix) bool {
+	addr = addr.Unmap()
+
+	if !addr.IsPrivate() && !addr.IsLoopback() && !addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() && !addr.IsUnspecified() {
+		return false
+	}
+
+	for _, prefix := range allowed {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newPrivateIPGuardTransport returns a transport refusing to connect to
+// private addresses outside of allowed. The check runs on the resolved
+// address when dialing, so DNS names pointing to private addresses are
+// refused too. Environment proxies are not used, as they would hide the
+// target address.
+func newPrivateIPGuardTransport(allowed []netip.Prefix) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("%w: %v", errPrivateProxyTarget, err)
+			}
+
+			if isPrivateProxyTarget(addrPort.Addr(), allowed) {
+				return fmt.Errorf("%w: %s", errPrivateProxyTarget, addrPort.Addr())
+			}
+
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return transport
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPrivateProxyTarget(t *testing.T) {
+	allowed := []netip.Prefix{netip.MustParsePrefix("10.1.0.0/16")}
+
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "8.8.8.8", want: false},
+		{addr: "10.0.0.1", want: true},
+		{addr: "10.1.2.3", want: false},
+		{addr: "172.16.0.1", want: true},
+		{addr: "192.168.1.1", want: true},
+		{addr: "127.0.0.1", want: true},
+		{addr: "169.254.169.254", want: true},
+		{addr: "::1", want: true},
+		{addr: "fe80::1", want: true},
+		{addr: "::ffff:192.168.1.1", want: true},
+		{addr: "0.0.0.0", want: true},
+		{addr: "2001:4860:4860::8888", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			assert.Equal(t, tt.want, isPrivateProxyTarget(netip.MustParseAddr(tt.addr), allowed))
+		})
+	}
+}
+
+func TestPrivateIPGuardTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	request := func(client *http.Client) error {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+
+		return err
+	}
+
+	client := &http.Client{Transport: newPrivateIPGuardTransport(nil)}
+	assert.ErrorIs(t, request(client), errPrivateProxyTarget)
+
+	client = &http.Client{Transport: newPrivateIPGuardTransport([]netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")})}
+	assert.NoError(t, request(client))
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubernetes-sigs/headlamp/backend/pkg/logger"
+)
+
+// rateLimitPruneInterval is how often idle buckets are dropped.
+const rateLimitPruneInterval = time.Minute
+
+// rateLimitIdleTimeout is how long a bucket is kept without requests, even if
+// it has not refilled yet, so the buckets of past clients do not pile up.
+const rateLimitIdleTimeout = 10 * time.Minute
+
+// tokenBucket holds the requests a single user can still make.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter limits the requests per second of each user, keyed by their
+// verified identity, or by client IP for other requests.
+type rateLimiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     float64
+	buckets   map[string]*tokenBucket
+	lastPrune time.Time
+	now       func() time.Time
+	user      func(r *http.Request) string
+	exempt    func(r *http.Request) bool
+	// trustedProxies are the proxies, like an ingress controller, whose
+	// X-Forwarded-For header is used for the client IP. Without them, all
+	// the clients behind a proxy share its bucket.
+	trustedProxies []netip.Prefix
+}
+
+// newRateLimiter returns a rateLimiter allowing rate requests per second,
+// with bursts of up to burst requests. user returns the verified identity of
+// a request, or "" to limit it by client IP, and exempt the requests that are
+// not limited. Both may be nil.
+func newRateLimiter(rate float64, burst int, user func(r *http.Request) string,
+	exempt func(r *http.Request) bool,
+) *rateLimiter {
+	return &rateLimiter{
+		rate:      rate,
+		burst:     float64(max(burst, 1)),
+		buckets:   make(map[string]*tokenBucket),
+		lastPrune: time.Now(),
+		now:       time.Now,
+		user:      user,
+		exempt:    exempt,
+	}
+}
+
+// allow takes a token from the bucket of key, returning false if it is empty.
+func (l *rateLimiter) allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	if now.Sub(l.lastPrune) >= rateLimitPruneInterval {
+		l.prune(now)
+	}
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = bucket
+	}
+
+	bucket.tokens = min(l.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+
+	bucket.tokens--
+
+	return true
+}
+
+// prune drops the buckets that have refilled completely, as they behave
+// the same as a new bucket, and those idle for rateLimitIdleTimeout.
+func (l *rateLimiter) prune(now time.Time) {
+	for key, bucket := range l.buckets {
+		idle := now.Sub(bucket.last)
+		if bucket.tokens+idle.Seconds()*l.rate >= l.burst || idle >= rateLimitIdleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+
+	l.lastPrune = now
+}
+
+// key returns the identity a request is rate limited by: a hash of its
+// verified user if any, else its client IP. Unverified credentials are not
+// used, as clients could get a new bucket for each request by changing them.
+func (l *rateLimiter) key(r *http.Request) string {
+	if l.user != nil {
+		if user := l.user(r); user != "" {
+			sum := sha256.Sum256([]byte(user))
+
+			return "user:" + hex.EncodeToString(sum[:])
+		}
+	}
+
+	return "ip:" + l.clientIP(r)
+}
+
+// clientIP returns the IP of the client of a request. When it comes through
+// trusted proxies, this is the last address of X-Forwarded-For that is not one
+// of them, as earlier ones are set by the client and can be forged.
+func (l *rateLimiter) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	if !l.isTrustedProxy(host) {
+		return host
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr := strings.TrimSpace(forwarded[i])
+		if addr == "" {
+			continue
+		}
+
+		host = addr
+
+		if !l.isTrustedProxy(addr) {
+			break
+		}
+	}
+
+	return host
+}
+
+// isTrustedProxy returns true if addr is in one of the trusted proxy ranges.
+func (l *rateLimiter) isTrustedProxy(addr string) bool {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return false
+	}
+
+	ip = ip.Unmap()
+
+	for _, prefix := range l.trustedProxies {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// middleware responds with 429 Too Many Requests to the requests over the
+// rate limit of their user.
+func (l *rateLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if l.exempt != nil && l.exempt(r) {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		if !l.allow(l.key(r)) {
+			logger.Log(logger.LevelWarn, map[string]string{"path": r.URL.Path}, nil, "request rate limited")
+
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitUser returns the bearer token of a request if it was issued through
+// the Headlamp OIDC login, which cached its refresh token once verified, else "".
+func (c *HeadlampConfig) rateLimitUser(r *http.Request) string {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		return ""
+	}
+
+	if _, err := c.sessions().Get(context.Background(), fmt.Sprintf("oidc-token-%s", token)); err != nil {
+		return ""
+	}
+
+	return token
+}
+
+// isRateLimitExempt returns true for the requests that are not rate limited:
+// the frontend config and the static files of the frontend and plugins, which
+// every page load fetches at once.
+func (c *HeadlampConfig) isRateLimitExempt(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, c.baseURL)
+	if path == "/config" || strings.HasPrefix(path, "/plugins/") || strings.HasPrefix(path, "/static-plugins/") {
+		return true
+	}
+
+	if c.staticDir == "" || strings.Contains(path, "..") {
+		return false
+	}
+
+	_, err := os.Stat(filepath.Join(c.staticDir, filepath.FromSlash(path)))
+
+	return err == nil
+}
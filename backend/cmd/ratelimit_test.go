@@ -0,0 +1,230 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kubernetes-sigs/headlamp/backend/pkg/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(1, 2, nil, nil)
+	limiter.now = func() time.Time { return now }
+
+	assert.True(t, limiter.allow("a"))
+	assert.True(t, limiter.allow("a"))
+	assert.False(t, limiter.allow("a"))
+
+	// Other users have their own bucket.
+	assert.True(t, limiter.allow("b"))
+
+	now = now.Add(time.Second)
+
+	assert.True(t, limiter.allow("a"))
+	assert.False(t, limiter.allow("a"))
+
+	// Refilled buckets are pruned.
+	now = now.Add(rateLimitPruneInterval)
+
+	assert.True(t, limiter.allow("a"))
+	assert.Len(t, limiter.buckets, 1)
+
+	// Idle buckets are pruned even if they have not refilled.
+	slow := newRateLimiter(0.001, 5, nil, nil)
+	slow.now = func() time.Time { return now }
+
+	assert.True(t, slow.allow("a"))
+
+	now = now.Add(rateLimitIdleTimeout)
+
+	assert.True(t, slow.allow("b"))
+	assert.Len(t, slow.buckets, 1)
+	assert.Contains(t, slow.buckets, "b")
+}
+
+func TestRateLimiterMiddleware(t *testing.T) {
+	user := func(r *http.Request) string {
+		if token := r.Header.Get("Authorization"); token != "Bearer unverified" {
+			return token
+		}
+
+		return ""
+	}
+	exempt := func(r *http.Request) bool { return r.URL.Path == "/config" }
+
+	limiter := newRateLimiter(1, 1, user, exempt)
+	handler := limiter.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func(remoteAddr, token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/clusters/minikube/api/v1/pods", nil)
+		req.RemoteAddr = remoteAddr
+
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request("10.0.0.1:1234", ""))
+	assert.Equal(t, http.StatusTooManyRequests, request("10.0.0.1:5678", ""))
+	assert.Equal(t, http.StatusOK, request("10.0.0.2:1234", ""))
+
+	// Verified users are limited by identity, not by IP.
+	assert.Equal(t, http.StatusOK, request("10.0.0.1:1234", "user-a"))
+	assert.Equal(t, http.StatusTooManyRequests, request("10.0.0.2:1234", "user-a"))
+	assert.Equal(t, http.StatusOK, request("10.0.0.1:1234", "user-b"))
+
+	// Unverified tokens are limited by IP.
+	assert.Equal(t, http.StatusTooManyRequests, request("10.0.0.2:1234", "unverified"))
+
+	// Exempt requests are not limited.
+	req := httptest.NewRequest(http.MethodGet, "/config", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestRateLimiterClientIP(t *testing.T) {
+	limiter := newRateLimiter(1, 1, nil, nil)
+	limiter.trustedProxies = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/24")}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{name: "direct", remoteAddr: "192.0.2.1:1234", want: "192.0.2.1"},
+		{
+			name: "untrusted_peer", remoteAddr: "192.0.2.1:1234", forwarded: []string{"198.51.100.7"},
+			want: "192.0.2.1",
+		},
+		{
+			name: "ingress", remoteAddr: "10.0.0.5:1234", forwarded: []string{"198.51.100.7"},
+			want: "198.51.100.7",
+		},
+		{
+			name: "forged_by_client", remoteAddr: "10.0.0.5:1234", forwarded: []string{"203.0.113.9, 198.51.100.7"},
+			want: "198.51.100.7",
+		},
+		{
+			name: "proxy_chain", remoteAddr: "10.0.0.5:1234", forwarded: []string{"198.51.100.7", "10.0.0.6"},
+			want: "198.51.100.7",
+		},
+		{name: "no_header", remoteAddr: "10.0.0.5:1234", want: "10.0.0.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/clusters/minikube/api/v1/pods", nil)
+			req.RemoteAddr = tt.remoteAddr
+
+			for _, forwarded := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", forwarded)
+			}
+
+			assert.Equal(t, tt.want, limiter.clientIP(req))
+		})
+	}
+
+	// Clients behind the ingress get their own bucket.
+	handler := limiter.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	request := func(forwarded string) int {
+		req := httptest.NewRequest(http.MethodGet, "/clusters/minikube/api/v1/pods", nil)
+		req.RemoteAddr = "10.0.0.5:1234"
+		req.Header.Set("X-Forwarded-For", forwarded)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request("198.51.100.7"))
+	assert.Equal(t, http.StatusTooManyRequests, request("198.51.100.7"))
+	assert.Equal(t, http.StatusOK, request("198.51.100.8"))
+}
+
+func TestRateLimitUser(t *testing.T) {
+	config := &HeadlampConfig{cache: cache.New[interface{}]()}
+	require.NoError(t, config.cache.Set(context.Background(), "oidc-token-verified", "refresh-token"))
+
+	request := func(token string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/clusters/minikube/api/v1/pods", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		return req
+	}
+
+	assert.Equal(t, "verified", config.rateLimitUser(request("verified")))
+	assert.Empty(t, config.rateLimitUser(request("forged")))
+	assert.Empty(t, config.rateLimitUser(request("")))
+}
+
+func TestIsRateLimitExempt(t *testing.T) {
+	staticDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(staticDir, "assets"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(staticDir, "assets", "index.js"), []byte("{}"), 0o600))
+
+	config := &HeadlampConfig{baseURL: "/headlamp", staticDir: staticDir}
+
+	tests := []struct {
+		method string
+		path   string
+		want   bool
+	}{
+		{method: http.MethodGet, path: "/headlamp/config", want: true},
+		{method: http.MethodGet, path: "/headlamp/assets/index.js", want: true},
+		{method: http.MethodGet, path: "/headlamp/plugins/my-plugin/main.js", want: true},
+		{method: http.MethodGet, path: "/headlamp/clusters/minikube/api/v1/pods", want: false},
+		{method: http.MethodGet, path: "/headlamp/assets/missing.js", want: false},
+		{method: http.MethodDelete, path: "/headlamp/plugins/my-plugin", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+
+			assert.Equal(t, tt.want, config.isRateLimitExempt(req))
+		})
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// isStreamingRequest returns true for requests that are meant to stay open:
+// watches, followed logs and connection upgrades, e.g. WebSockets for exec
+// or the multiplexer.
+func isStreamingRequest(r *http.Request) bool {
+	if r.Header.Get("Upgrade") != "" {
+		return true
+	}
+
+	query := r.URL.Query()
+
+	for _, param := range []string{"watch", "follow"} {
+		if enabled, err := strconv.ParseBool(query.Get(param)); err == nil && enabled {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newRequestTimeoutHandler sets a deadline of timeout on the context of every
+// request except streaming ones, which bounds the request to the cluster too.
+func newRequestTimeoutHandler(timeout time.Duration, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isStreamingRequest(r) {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
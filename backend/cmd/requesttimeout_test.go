@@ -0,0 +1,71 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestTimeoutHandler(t *testing.T) {
+	var deadline time.Time
+
+	var hasDeadline bool
+
+	handler := newRequestTimeoutHandler(time.Minute, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, hasDeadline = r.Context().Deadline()
+	}))
+
+	tests := []struct {
+		name      string
+		target    string
+		upgrade   string
+		streaming bool
+	}{
+		{name: "list", target: "/clusters/main/api/v1/pods"},
+		{name: "watch", target: "/clusters/main/api/v1/pods?watch=true", streaming: true},
+		{name: "watch_1", target: "/clusters/main/api/v1/pods?watch=1", streaming: true},
+		{name: "no_watch", target: "/clusters/main/api/v1/pods?watch=false"},
+		{name: "follow_logs", target: "/clusters/main/api/v1/namespaces/default/pods/p/log?follow=true", streaming: true},
+		{name: "websocket", target: "/wsMultiplexer", upgrade: "websocket", streaming: true},
+		{name: "spdy", target: "/clusters/main/api/v1/namespaces/default/pods/p/exec", upgrade: "SPDY/3.1", streaming: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.upgrade != "" {
+				req.Header.Set("Connection", "Upgrade")
+				req.Header.Set("Upgrade", tt.upgrade)
+			}
+
+			start := time.Now()
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.streaming, isStreamingRequest(req))
+			assert.Equal(t, !tt.streaming, hasDeadline)
+
+			if !tt.streaming {
+				assert.WithinDuration(t, start.Add(time.Minute), deadline, 5*time.Second)
+			}
+		})
+	}
+}
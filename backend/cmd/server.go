@@ -18,6 +18,7 @@ package main
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/kubernetes-sigs/headlamp/backend/pkg/cache"
@@ -40,29 +41,131 @@ func main() {
 		os.Exit(1)
 	}
 
+	cleanup := func() {
+		if err := conf.Cleanup(); err != nil {
+			logger.Log(logger.LevelError, nil, err, "cleaning up config files")
+		}
+	}
+	defer cleanup()
+
+	// exit removes the temporary config files, like the decoded
+	// kubeconfig-base64, which os.Exit would skip.
+	exit := func(code int) {
+		cleanup()
+		os.Exit(code)
+	}
+
+	watchdog := newStartupWatchdog(conf.StartupTimeout, exit)
+	defer watchdog.Done()
+
+	watchdog.Step("finding the default context")
+
+	defaultContext, err := conf.DefaultContext()
+	if err != nil {
+		logger.Log(logger.LevelWarn, nil, err, "finding the default context")
+	}
+
+	if conf.OidcClientID != "" && conf.OidcAllowedRedirectHosts == "" {
+		logger.Log(logger.LevelWarn, nil, nil,
+			"oidc-allowed-redirect-hosts is not set, the OIDC callback URL is built from the host of the request")
+	}
+
+	cookieEncryptionKey, err := conf.CookieEncryptionKeyBytes()
+	if err != nil {
+		logger.Log(logger.LevelError, nil, err, "decoding cookie encryption key")
+		exit(1)
+	}
+
+	watchdog.Step("reading proxy URLs")
+
+	proxyURLs, err := conf.ProxyURLPatterns()
+	if err != nil {
+		logger.Log(logger.LevelError, nil, err, "parsing proxy URLs")
+		exit(1)
+	}
+
+	watchdog.Step("checking temp dir")
+
+	if err := conf.CheckTempDir(); err != nil {
+		logger.Log(logger.LevelError, nil, err, "checking temp dir")
+		exit(1)
+	}
+
 	cache := cache.New[interface{}]()
-	kubeConfigStore := kubeconfig.NewContextStore()
+
+	watchdog.Step("loading the session store")
+
+	sessionCache, err := newSessionCache(conf.SessionStore, conf.SessionStoreDir)
+	if err != nil {
+		logger.Log(logger.LevelError, nil, err, "creating session store")
+		exit(1)
+	}
+
+	// The config is only valid if the server could start with it, so the
+	// temp dir and session store are checked too.
+	if conf.CheckConfig {
+		logger.Log(logger.LevelInfo, nil, nil, "config is valid")
+
+		return
+	}
+
+	kubeConfigStore := kubeconfig.NewContextStoreWithIdleConnLimits(kubeconfig.IdleConnLimits{
+		MaxIdleConns:        conf.APIMaxIdleConns,
+		MaxIdleConnsPerHost: conf.APIMaxIdleConnsPerHost,
+	})
 	multiplexer := NewMultiplexer(kubeConfigStore)
 
 	StartHeadlampServer(&HeadlampConfig{
 		useInCluster:              conf.InCluster,
-		kubeConfigPath:            conf.KubeConfigPath,
-		skippedKubeContexts:       conf.SkippedKubeContexts,
+		kubeConfigPath:            strings.Join(conf.KubeConfigPaths(), string(filepath.ListSeparator)),
+		skippedKubeContexts:       conf.SkippedContexts(),
+		defaultCluster:            kubeconfig.MakeDNSFriendly(defaultContext),
 		listenAddr:                conf.ListenAddr,
 		port:                      conf.Port,
 		devMode:                   conf.DevMode,
 		staticDir:                 conf.StaticDir,
+		staticCacheControl:        conf.StaticCacheControl,
+		tempDir:                   conf.TempDir,
 		insecure:                  conf.InsecureSsl,
 		pluginDir:                 conf.PluginsDir,
 		oidcClientID:              conf.OidcClientID,
-		oidcValidatorClientID:     conf.OidcValidatorClientID,
+		oidcValidatorClientIDs:    conf.ValidatorClientIDs(),
+		oidcValidatorClientID:     conf.EffectiveValidatorClientID(),
 		oidcClientSecret:          conf.OidcClientSecret,
 		oidcIdpIssuerURL:          conf.OidcIdpIssuerURL,
 		oidcValidatorIdpIssuerURL: conf.OidcValidatorIdpIssuerURL,
-		oidcScopes:                strings.Split(conf.OidcScopes, ","),
+		oidcValidatorIssuer:       conf.EffectiveValidatorIssuer(),
+		oidcIssuerAliases:         conf.OidcIssuerAliasList(),
+		oidcScopes:                conf.EffectiveOidcScopes(),
 		oidcUseAccessToken:        conf.OidcUseAccessToken,
+		oidcUserInfoEnabled:       conf.OidcUserInfoEnabled,
+		oidcGroupsFromUserInfo:    conf.OidcGroupsFromUserInfo,
+		oidcSessionTTL:            conf.OidcSessionTTL,
+		oidcMaxTokenSize:          conf.OidcMaxTokenSize,
+		oidcAllowedRedirectHosts:  conf.OidcAllowedRedirectHostList(),
+		oidcTLSServerName:         conf.OidcTLSServerName,
+		oidcProviders:             newOIDCProviderCache(conf.OidcDiscoveryCacheTTL),
+		cookieEncryptionKey:       cookieEncryptionKey,
+		sessionCache:              sessionCache,
 		baseURL:                   conf.BaseURL,
-		proxyURLs:                 strings.Split(conf.ProxyURLs, ","),
+		proxyURLs:                 proxyURLs,
+		proxyURLsFile:             conf.ProxyURLsFile,
+		proxyURLsRefresh:          conf.ProxyURLsRefresh,
+		proxyStripResponseHeaders: conf.ProxyStripResponseHeaderList(),
+		proxyAddRequestHeaders:    conf.ProxyAddRequestHeaderMap(),
+		blockPrivateProxyTargets:  conf.BlockPrivateProxyTargets,
+		allowPrivateProxyTargets:  conf.AllowPrivateProxyTargetList(),
+		listChunkSize:             conf.ListChunkSize,
+		startupWatchdog:           watchdog,
+		exit:                      exit,
+		watchDrainTimeout:         conf.WatchDrainTimeout,
+		globalRequestTimeout:      conf.GlobalRequestTimeout,
+		perUserRateLimit:          conf.PerUserRateLimit,
+		perUserRateBurst:          conf.EffectivePerUserRateBurst(),
+		perUserRateTrustedProxies: conf.PerUserRateTrustedProxyList(),
+		accessLog:                 conf.AccessLog,
+		accessLogFormat:           conf.AccessLogFormat,
+		logRedactParams:           conf.LogRedactParamList(),
 		enableHelm:                conf.EnableHelm,
 		enableDynamicClusters:     conf.EnableDynamicClusters,
 		watchPluginsChanges:       conf.WatchPluginsChanges,
@@ -70,15 +173,20 @@ func main() {
 		kubeConfigStore:           kubeConfigStore,
 		multiplexer:               multiplexer,
 		telemetryConfig: config.Config{
-			ServiceName:        conf.ServiceName,
-			ServiceVersion:     conf.ServiceVersion,
-			TracingEnabled:     conf.TracingEnabled,
-			MetricsEnabled:     conf.MetricsEnabled,
-			JaegerEndpoint:     conf.JaegerEndpoint,
-			OTLPEndpoint:       conf.OTLPEndpoint,
-			UseOTLPHTTP:        conf.UseOTLPHTTP,
-			StdoutTraceEnabled: conf.StdoutTraceEnabled,
-			SamplingRate:       conf.SamplingRate,
+			ServiceName:                  conf.ServiceName,
+			ServiceVersion:               conf.ServiceVersion,
+			TracingEnabled:               conf.TracingEnabled,
+			MetricsEnabled:               conf.MetricsEnabled,
+			JaegerEndpoint:               conf.JaegerEndpoint,
+			OTLPEndpoint:                 conf.OTLPEndpoint,
+			UseOTLPHTTP:                  conf.UseOTLPHTTP,
+			OTLPInsecure:                 conf.OTLPInsecure,
+			StdoutTraceEnabled:           conf.StdoutTraceEnabled,
+			SamplingRate:                 conf.SamplingRate,
+			MetricsExcludePaths:          conf.MetricsExcludePaths,
+			DisableBuildInfoMetric:       conf.DisableBuildInfoMetric,
+			MetricsLatencyBuckets:        conf.MetricsLatencyBuckets,
+			TelemetryExcludeClusterLabel: conf.TelemetryExcludeClusterLabel,
 		},
 	})
 }
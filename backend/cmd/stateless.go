@@ -180,7 +180,8 @@ func (c *HeadlampConfig) parseKubeConfig(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	clientConfig := clientConfig{contexts, c.enableDynamicClusters}
+	// The default cluster comes from the server kubeconfig, so it does not apply here.
+	clientConfig := clientConfig{contexts, c.enableDynamicClusters, c.listChunkSize, ""}
 
 	if err := json.NewEncoder(w).Encode(&clientConfig); err != nil {
 		logger.Log(logger.LevelError, nil, err, "encoding config")
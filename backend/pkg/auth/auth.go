@@ -17,8 +17,12 @@ limitations under the License.
 package auth
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 )
 
 // DecodeBase64JSON decodes a base64 URL-encoded JSON string into a map.
@@ -35,3 +39,58 @@ func DecodeBase64JSON(base64JSON string) (map[string]interface{}, error) {
 
 	return payloadMap, nil
 }
+
+// EncryptCookieValue encrypts a cookie value with AES-GCM, so it can be
+// stored on the client side. The key must be 32 bytes long.
+func EncryptCookieValue(key []byte, value string) (string, error) {
+	gcm, err := newCookieCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(value), nil)), nil
+}
+
+// DecryptCookieValue decrypts a cookie value encrypted by EncryptCookieValue
+// with the same key.
+func DecryptCookieValue(key []byte, encrypted string) (string, error) {
+	gcm, err := newCookieCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("encrypted cookie value is too short")
+	}
+
+	value, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(value), nil
+}
+
+// newCookieCipher returns the AES-256-GCM cipher for a cookie encryption key.
+func newCookieCipher(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, errors.New("cookie encryption key needs to be 32 bytes long")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package auth_test
 
 import (
+	"bytes"
 	"reflect"
 	"testing"
 
@@ -89,3 +90,37 @@ func TestDecodeBase64JSON(t *testing.T) {
 		})
 	}
 }
+
+func TestEncryptCookieValue(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+
+	encrypted, err := auth.EncryptCookieValue(key, "session-data")
+	if err != nil {
+		t.Fatalf("EncryptCookieValue() error = %v", err)
+	}
+
+	if encrypted == "session-data" {
+		t.Errorf("EncryptCookieValue() did not encrypt the value")
+	}
+
+	got, err := auth.DecryptCookieValue(key, encrypted)
+	if err != nil {
+		t.Fatalf("DecryptCookieValue() error = %v", err)
+	}
+
+	if got != "session-data" {
+		t.Errorf("DecryptCookieValue() = %v, want %v", got, "session-data")
+	}
+
+	if _, err := auth.DecryptCookieValue(bytes.Repeat([]byte{2}, 32), encrypted); err == nil {
+		t.Errorf("DecryptCookieValue() with another key should fail")
+	}
+
+	if _, err := auth.DecryptCookieValue(key, "short"); err == nil {
+		t.Errorf("DecryptCookieValue() of a truncated value should fail")
+	}
+
+	if _, err := auth.EncryptCookieValue([]byte("short"), "session-data"); err == nil {
+		t.Errorf("EncryptCookieValue() with a short key should fail")
+	}
+}
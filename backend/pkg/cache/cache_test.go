@@ -18,6 +18,9 @@ package cache_test
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -108,3 +111,122 @@ func TestCacheGetAll(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(values))
 }
+
+func TestFileCache(t *testing.T) {
+	ch, err := cache.NewFile[interface{}](filepath.Join(t.TempDir(), "cache"))
+	require.NoError(t, err)
+	testCache(ch, t)
+}
+
+func TestFileCachePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+
+	ch, err := cache.NewFile[interface{}](path)
+	require.NoError(t, err)
+
+	require.NoError(t, ch.Set(context.Background(), "key1", "value1"))
+	require.NoError(t, ch.SetWithTTL(context.Background(), "ttlkey1", "value1", time.Millisecond))
+	require.NoError(t, ch.Set(context.Background(), "key2", "value2"))
+	require.NoError(t, ch.Delete(context.Background(), "key2"))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	time.Sleep(10 * time.Millisecond)
+
+	// a new cache on the same file only loads the values still present
+	ch, err = cache.NewFile[interface{}](path)
+	require.NoError(t, err)
+
+	value, err := ch.Get(context.Background(), "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "value1", value)
+
+	_, err = ch.Get(context.Background(), "ttlkey1")
+	assert.Equal(t, cache.ErrNotFound, err)
+
+	_, err = ch.Get(context.Background(), "key2")
+	assert.Equal(t, cache.ErrNotFound, err)
+}
+
+func TestFileCacheInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	require.NoError(t, os.WriteFile(path, []byte("not a cache"), 0o600))
+
+	_, err := cache.NewFile[interface{}](path)
+	assert.Error(t, err)
+}
+
+func TestFileCacheCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+
+	ch, err := cache.NewFile[interface{}](path)
+	require.NoError(t, err)
+
+	require.NoError(t, ch.Set(context.Background(), "key", "value"))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	// Changes are appended, and the file is compacted before it grows much.
+	for i := range 10000 {
+		require.NoError(t, ch.Set(context.Background(), "key", fmt.Sprintf("value%d", i)))
+	}
+
+	compacted, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Less(t, compacted.Size(), info.Size()+100*1024)
+
+	ch, err = cache.NewFile[interface{}](path)
+	require.NoError(t, err)
+
+	value, err := ch.Get(context.Background(), "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value9999", value)
+}
+
+func TestFileCachePrunesOnLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+
+	ch, err := cache.NewFile[interface{}](path)
+	require.NoError(t, err)
+
+	require.NoError(t, ch.Set(context.Background(), "key1", "value1"))
+	require.NoError(t, ch.SetWithTTL(context.Background(), "expiredkey", "secret", time.Millisecond))
+
+	time.Sleep(10 * time.Millisecond)
+
+	_, err = cache.NewFile[interface{}](path)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "key1")
+	assert.NotContains(t, string(content), "expiredkey")
+}
+
+func TestFileCacheTruncatedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+
+	ch, err := cache.NewFile[interface{}](path)
+	require.NoError(t, err)
+
+	require.NoError(t, ch.Set(context.Background(), "key1", "value1"))
+	require.NoError(t, ch.Set(context.Background(), "key2", "value2"))
+
+	// A record cut short while it was written is dropped.
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, info.Size()-2))
+
+	ch, err = cache.NewFile[interface{}](path)
+	require.NoError(t, err)
+
+	value, err := ch.Get(context.Background(), "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "value1", value)
+
+	_, err = ch.Get(context.Background(), "key2")
+	assert.Equal(t, cache.ErrNotFound, err)
+}
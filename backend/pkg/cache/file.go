@@ -0,0 +1,266 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// compactMinRecords is the number of records the file of a fileCache can
+// hold before it is compacted, which then happens once it holds more than
+// twice as many records as there are values.
+const compactMinRecords = 256
+
+// fileHeader starts the file of a fileCache, telling it apart from other files.
+const fileHeader = "headlamp-cache-v1"
+
+// fileEntry is a cached value as stored in the file of a fileCache.
+type fileEntry[T any] struct {
+	Value     T
+	ExpiresAt time.Time
+}
+
+// fileRecord is a change to a fileCache, as appended to its file.
+type fileRecord[T any] struct {
+	Key     string
+	Entry   fileEntry[T]
+	Deleted bool
+}
+
+// fileCache is a cache that appends its changes to a file, so they survive
+// restarts. The file is compacted to the current values when it is loaded
+// and once it holds too many stale records.
+type fileCache[T any] struct {
+	*cache[T]
+	path     string
+	saveLock sync.Mutex
+	file     *os.File
+	encoder  *gob.Encoder
+	records  int
+}
+
+// NewFile creates a new cache persisted to the file at path, loading the
+// values already stored there. Values are gob encoded, so when T is an
+// interface the concrete types stored need to be registered with gob.Register.
+func NewFile[T any](path string) (Cache[T], error) {
+	c := &fileCache[T]{
+		cache: &cache[T]{
+			store:           make(map[string]cacheValue[T]),
+			cleanUpInterval: cleanUpInterval,
+		},
+		path: path,
+	}
+
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+
+	// Rewriting the file right away drops the expired values it still holds.
+	if err := c.compact(); err != nil {
+		return nil, err
+	}
+
+	go c.cleanUp()
+
+	return c, nil
+}
+
+// Set stores a value in the cache.
+func (c *fileCache[T]) Set(ctx context.Context, key string, value T) error {
+	return c.SetWithTTL(ctx, key, value, 0)
+}
+
+// SetWithTTL stores a value in the cache with a TTL.
+func (c *fileCache[T]) SetWithTTL(ctx context.Context, key string, value T, ttl time.Duration) error {
+	c.saveLock.Lock()
+	defer c.saveLock.Unlock()
+
+	if err := c.cache.SetWithTTL(ctx, key, value, ttl); err != nil {
+		return err
+	}
+
+	return c.appendValue(key)
+}
+
+// Delete removes a value from the cache.
+func (c *fileCache[T]) Delete(ctx context.Context, key string) error {
+	c.saveLock.Lock()
+	defer c.saveLock.Unlock()
+
+	if err := c.cache.Delete(ctx, key); err != nil {
+		return err
+	}
+
+	return c.appendRecord(fileRecord[T]{Key: key, Deleted: true})
+}
+
+// UpdateTTL updates the TTL of a value in the cache.
+func (c *fileCache[T]) UpdateTTL(ctx context.Context, key string, ttl time.Duration) error {
+	c.saveLock.Lock()
+	defer c.saveLock.Unlock()
+
+	if err := c.cache.UpdateTTL(ctx, key, ttl); err != nil {
+		return err
+	}
+
+	return c.appendValue(key)
+}
+
+// load reads the records stored in the file, skipping the expired values.
+// A record cut short, e.g. by a crash while it was written, ends the file.
+func (c *fileCache[T]) load() error {
+	file, err := os.Open(c.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("opening cache file: %w", err)
+	}
+
+	defer file.Close()
+
+	decoder := gob.NewDecoder(file)
+
+	var header string
+	if err := decoder.Decode(&header); err != nil || header != fileHeader {
+		return fmt.Errorf("decoding cache file %s: not a cache file", c.path)
+	}
+
+	for {
+		var record fileRecord[T]
+
+		err := decoder.Decode(&record)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("decoding cache file %s: %w", c.path, err)
+		}
+
+		if record.Deleted {
+			delete(c.store, record.Key)
+		} else {
+			c.store[record.Key] = cacheValue[T]{value: record.Entry.Value, expiresAt: record.Entry.ExpiresAt}
+		}
+	}
+
+	now := time.Now()
+
+	for key, value := range c.store {
+		if !value.expiresAt.IsZero() && !value.expiresAt.After(now) {
+			delete(c.store, key)
+		}
+	}
+
+	return nil
+}
+
+// appendValue appends the current value of key to the file.
+func (c *fileCache[T]) appendValue(key string) error {
+	c.lock.RLock()
+	value, ok := c.store[key]
+	c.lock.RUnlock()
+
+	if !ok {
+		return nil
+	}
+
+	return c.appendRecord(fileRecord[T]{Key: key, Entry: fileEntry[T]{Value: value.value, ExpiresAt: value.expiresAt}})
+}
+
+// appendRecord appends a change to the file, compacting it once most of its
+// records are stale. The caller holds saveLock.
+func (c *fileCache[T]) appendRecord(record fileRecord[T]) error {
+	if err := c.encoder.Encode(record); err != nil {
+		return fmt.Errorf("writing cache file: %w", err)
+	}
+
+	c.records++
+
+	c.lock.RLock()
+	values := len(c.store)
+	c.lock.RUnlock()
+
+	if c.records <= max(compactMinRecords, 2*values) {
+		return nil
+	}
+
+	return c.compact()
+}
+
+// compact replaces the file with one holding a record per current value,
+// which further changes are appended to. The file is only readable by the
+// owner, as it may hold credentials.
+func (c *fileCache[T]) compact() error {
+	file, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return fmt.Errorf("creating cache file: %w", err)
+	}
+
+	encoder := gob.NewEncoder(file)
+
+	records, err := c.writeValues(encoder)
+	if err == nil {
+		err = os.Rename(file.Name(), c.path)
+	}
+
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+
+		return fmt.Errorf("writing cache file: %w", err)
+	}
+
+	if c.file != nil {
+		c.file.Close()
+	}
+
+	c.file, c.encoder, c.records = file, encoder, records
+
+	return nil
+}
+
+// writeValues encodes the file header and a record per current value,
+// returning the number of records.
+func (c *fileCache[T]) writeValues(encoder *gob.Encoder) (int, error) {
+	if err := encoder.Encode(fileHeader); err != nil {
+		return 0, err
+	}
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	for key, value := range c.store {
+		record := fileRecord[T]{Key: key, Entry: fileEntry[T]{Value: value.value, ExpiresAt: value.expiresAt}}
+		if err := encoder.Encode(record); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(c.store), nil
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a size in bytes, set in config with a humanized value such as
+// 512, 64KB or 1.5MiB.
+type ByteSize int64
+
+// byteSizeUnits maps the lowercase units accepted by ParseByteSize to their
+// size in bytes. KB, MB and GB are decimal, KiB, MiB and GiB are binary.
+var byteSizeUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"k":   1e3,
+	"kb":  1e3,
+	"ki":  1 << 10,
+	"kib": 1 << 10,
+	"m":   1e6,
+	"mb":  1e6,
+	"mi":  1 << 20,
+	"mib": 1 << 20,
+	"g":   1e9,
+	"gb":  1e9,
+	"gi":  1 << 30,
+	"gib": 1 << 30,
+}
+
+// byteSizeValue matches a number followed by an optional unit.
+var byteSizeValue = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)\s*([A-Za-z]*)$`)
+
+// ParseByteSize parses a humanized size, e.g. 512, 64KB or 1.5MiB.
+func ParseByteSize(value string) (ByteSize, error) {
+	matches := byteSizeValue.FindStringSubmatch(strings.TrimSpace(value))
+	if matches == nil {
+		return 0, fmt.Errorf("invalid size %q, expected a number with an optional unit, e.g. 64KB or 1MiB", value)
+	}
+
+	unit, ok := byteSizeUnits[strings.ToLower(matches[2])]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit %q", value, matches[2])
+	}
+
+	number, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", value, err)
+	}
+
+	size := math.Round(number * unit)
+	if size > math.MaxInt64 {
+		return 0, fmt.Errorf("invalid size %q: too large", value)
+	}
+
+	return ByteSize(size), nil
+}
+
+// UnmarshalText lets config sources set a ByteSize with a humanized value.
+func (s *ByteSize) UnmarshalText(text []byte) error {
+	size, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+
+	*s = size
+
+	return nil
+}
+
+// String formats the size with the largest binary unit dividing it exactly.
+func (s ByteSize) String() string {
+	for _, unit := range []struct {
+		suffix string
+		size   ByteSize
+	}{{"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10}} {
+		if s != 0 && s%unit.size == 0 {
+			return fmt.Sprintf("%d%s", s/unit.size, unit.suffix)
+		}
+	}
+
+	return fmt.Sprintf("%dB", int64(s))
+}
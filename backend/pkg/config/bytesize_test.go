@@ -0,0 +1,50 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/kubernetes-sigs/headlamp/backend/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    config.ByteSize
+		wantErr bool
+	}{
+		{value: "512", want: 512},
+		{value: "512B", want: 512},
+		{value: "64KB", want: 64000},
+		{value: "64kib", want: 64 << 10},
+		{value: "64 KiB", want: 64 << 10},
+		{value: "1.5MiB", want: 3 << 19},
+		{value: "2G", want: 2e9},
+		{value: "", wantErr: true},
+		{value: "-1KB", wantErr: true},
+		{value: "10XB", wantErr: true},
+		{value: "KB", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := config.ParseByteSize(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestByteSizeString(t *testing.T) {
+	assert.Equal(t, "0B", config.ByteSize(0).String())
+	assert.Equal(t, "1000B", config.ByteSize(1000).String())
+	assert.Equal(t, "64KiB", config.ByteSize(64<<10).String())
+	assert.Equal(t, "3MiB", config.ByteSize(3<<20).String())
+}
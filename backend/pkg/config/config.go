@@ -1,46 +1,153 @@
 package config
 
 import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"flag"
 	"fmt"
 	"io/fs"
+	"maps"
+	"math"
+	"net/http"
+	"net/netip"
+	"net/url"
 	"os"
 	"os/user"
 	"path/filepath"
+	"reflect"
+	"regexp"
 	"runtime"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/gobwas/glob"
 	"github.com/knadh/koanf"
-	"github.com/knadh/koanf/providers/basicflag"
-	"github.com/knadh/koanf/providers/env"
+	"github.com/knadh/koanf/providers/confmap"
 	"github.com/kubernetes-sigs/headlamp/backend/pkg/logger"
+	"sigs.k8s.io/yaml"
 )
 
 const defaultPort = 4466
 
+// envPrefix is the prefix of the env vars read by Parse.
+const envPrefix = "HEADLAMP_CONFIG_"
+
+// Config sources, as named in config-precedence.
+const (
+	configSourceFlag = "flag"
+	configSourceEnv  = "env"
+	configSourceFile = "file"
+)
+
+// defaultConfigPrecedence lists the config sources from the highest to the
+// lowest priority.
+const defaultConfigPrecedence = "flag,env,file"
+
+// defaultStaticCacheControl is the Cache-Control header for static assets.
+const defaultStaticCacheControl = "public, max-age=3600"
+
+// Session stores, as named in session-store.
+const (
+	SessionStoreMemory = "memory"
+	SessionStoreFile   = "file"
+)
+
+// Access log formats, as named in access-log-format.
+const (
+	AccessLogFormatCombined = "combined"
+	AccessLogFormatJSON     = "json"
+)
+
+// defaultLogRedactParams are the query parameters carrying secrets, like the
+// code and state of the OIDC callback.
+const defaultLogRedactParams = "code,state,token"
+
+// Fallbacks for default-context-fallback, which can also be a context name.
+const (
+	DefaultContextFallbackFirst = "first"
+	DefaultContextFallbackNone  = "none"
+)
+
+// defaultProxyStripResponseHeaders are the hop-by-hop headers of RFC 7230,
+// which only apply to a single connection.
+const defaultProxyStripResponseHeaders = "Connection,Keep-Alive,Proxy-Authenticate,Proxy-Authorization," +
+	"Te,Trailer,Transfer-Encoding,Upgrade"
+
+// cookieEncryptionKeySize is the key size of the AES-256 cookie cipher.
+const cookieEncryptionKeySize = 32
+
+// defaultOidcMaxTokenSize is well above the tokens of common providers,
+// even with many groups, so it only catches runaway tokens.
+const defaultOidcMaxTokenSize ByteSize = 64 << 10
+
+// defaultListChunkSize matches the page size used by client-go's pager.
+const defaultListChunkSize = 500
+
 type Config struct {
-	InCluster                 bool   `koanf:"in-cluster"`
-	DevMode                   bool   `koanf:"dev"`
-	InsecureSsl               bool   `koanf:"insecure-ssl"`
-	EnableHelm                bool   `koanf:"enable-helm"`
-	EnableDynamicClusters     bool   `koanf:"enable-dynamic-clusters"`
-	ListenAddr                string `koanf:"listen-addr"`
-	WatchPluginsChanges       bool   `koanf:"watch-plugins-changes"`
-	Port                      uint   `koanf:"port"`
-	KubeConfigPath            string `koanf:"kubeconfig"`
-	SkippedKubeContexts       string `koanf:"skipped-kube-contexts"`
-	StaticDir                 string `koanf:"html-static-dir"`
-	PluginsDir                string `koanf:"plugins-dir"`
-	BaseURL                   string `koanf:"base-url"`
-	ProxyURLs                 string `koanf:"proxy-urls"`
-	OidcClientID              string `koanf:"oidc-client-id"`
-	OidcValidatorClientID     string `koanf:"oidc-validator-client-id"`
-	OidcClientSecret          string `koanf:"oidc-client-secret"`
-	OidcIdpIssuerURL          string `koanf:"oidc-idp-issuer-url"`
-	OidcValidatorIdpIssuerURL string `koanf:"oidc-validator-idp-issuer-url"`
-	OidcScopes                string `koanf:"oidc-scopes"`
-	OidcUseAccessToken        bool   `koanf:"oidc-use-access-token"`
+	ConfigFile                string        `koanf:"config-file"`
+	ConfigPrecedence          string        `koanf:"config-precedence"`
+	CheckConfig               bool          `koanf:"check-config"`
+	InCluster                 bool          `koanf:"in-cluster"`
+	DisableInClusterDetection bool          `koanf:"disable-in-cluster-detection"`
+	DevMode                   bool          `koanf:"dev"`
+	DisableDevEndpoints       bool          `koanf:"disable-dev-endpoints"`
+	InsecureSsl               bool          `koanf:"insecure-ssl"`
+	EnableHelm                bool          `koanf:"enable-helm"`
+	EnableDynamicClusters     bool          `koanf:"enable-dynamic-clusters"`
+	ListenAddr                string        `koanf:"listen-addr"`
+	WatchPluginsChanges       bool          `koanf:"watch-plugins-changes"`
+	Port                      uint          `koanf:"port"`
+	KubeConfigPath            string        `koanf:"kubeconfig"`
+	KubeConfigBase64          string        `koanf:"kubeconfig-base64"`
+	SkippedKubeContexts       string        `koanf:"skipped-kube-contexts"`
+	DefaultContextFallback    string        `koanf:"default-context-fallback"`
+	StaticDir                 string        `koanf:"html-static-dir"`
+	StaticCacheControl        string        `koanf:"static-cache-control"`
+	TempDir                   string        `koanf:"temp-dir"`
+	PluginsDir                string        `koanf:"plugins-dir"`
+	BaseURL                   string        `koanf:"base-url"`
+	ProxyURLs                 string        `koanf:"proxy-urls"`
+	ProxyURLsFile             string        `koanf:"proxy-urls-file"`
+	ProxyURLsRefresh          time.Duration `koanf:"proxy-urls-refresh"`
+	ProxyStripResponseHeaders string        `koanf:"proxy-strip-response-headers"`
+	ProxyAddRequestHeaders    string        `koanf:"proxy-add-request-headers"`
+	BlockPrivateProxyTargets  bool          `koanf:"block-private-proxy-targets"`
+	AllowPrivateProxyTargets  string        `koanf:"allow-private-proxy-targets"`
+	ListChunkSize             int           `koanf:"list-chunk-size"`
+	APIMaxIdleConns           int           `koanf:"api-max-idle-conns"`
+	APIMaxIdleConnsPerHost    int           `koanf:"api-max-idle-conns-per-host"`
+	StartupTimeout            time.Duration `koanf:"startup-timeout"`
+	WatchDrainTimeout         time.Duration `koanf:"watch-drain-timeout"`
+	GlobalRequestTimeout      time.Duration `koanf:"global-request-timeout"`
+	PerUserRateLimit          float64       `koanf:"per-user-rate-limit"`
+	PerUserRateBurst          int           `koanf:"per-user-rate-burst"`
+	PerUserRateTrustedProxies string        `koanf:"per-user-rate-limit-trusted-proxies"`
+	AccessLog                 bool          `koanf:"access-log"`
+	AccessLogFormat           string        `koanf:"access-log-format"`
+	LogRedactParams           string        `koanf:"log-redact-params"`
+	OidcClientID              string        `koanf:"oidc-client-id"`
+	OidcValidatorClientID     string        `koanf:"oidc-validator-client-id"`
+	OidcClientSecret          string        `koanf:"oidc-client-secret"`
+	OidcIdpIssuerURL          string        `koanf:"oidc-idp-issuer-url"`
+	OidcValidatorIdpIssuerURL string        `koanf:"oidc-validator-idp-issuer-url"`
+	OidcIssuerAliases         string        `koanf:"oidc-issuer-alias"`
+	OidcScopes                string        `koanf:"oidc-scopes"`
+	OidcUseAccessToken        bool          `koanf:"oidc-use-access-token"`
+	OidcUserInfoEnabled       bool          `koanf:"oidc-userinfo-enabled"`
+	OidcGroupsFromUserInfo    bool          `koanf:"oidc-groups-from-userinfo"`
+	OidcSessionTTL            time.Duration `koanf:"oidc-session-ttl"`
+	OidcMaxTokenSize          ByteSize      `koanf:"oidc-max-token-size"`
+	OidcAllowedRedirectHosts  string        `koanf:"oidc-allowed-redirect-hosts"`
+	OidcTLSServerName         string        `koanf:"oidc-tls-server-name"`
+	OidcDiscoveryCacheTTL     time.Duration `koanf:"oidc-discovery-cache-ttl"`
+	CookieEncryptionKey       string        `koanf:"oidc-cookie-encryption-key"`
+	CookieEncryptionKeyFile   string        `koanf:"oidc-cookie-encryption-key-file"`
+	SessionStore              string        `koanf:"session-store"`
+	SessionStoreDir           string        `koanf:"session-store-dir"`
 	// telemetry configs
 	ServiceName        string   `koanf:"service-name"`
 	ServiceVersion     *string  `koanf:"service-version"`
@@ -49,53 +156,1005 @@ type Config struct {
 	JaegerEndpoint     *string  `koanf:"jaeger-endpoint"`
 	OTLPEndpoint       *string  `koanf:"otlp-endpoint"`
 	UseOTLPHTTP        *bool    `koanf:"use-otlp-http"`
+	OTLPInsecure       *bool    `koanf:"otlp-insecure"`
 	StdoutTraceEnabled *bool    `koanf:"stdout-trace-enabled"`
 	SamplingRate       *float64 `koanf:"sampling-rate"`
+	// MetricsExcludePaths is a comma separated list of paths not recorded in
+	// request metrics. An entry ending in "*" matches by prefix.
+	MetricsExcludePaths string `koanf:"metrics-exclude-paths"`
+	// MetricsLatencyBuckets is a comma separated list of the bucket
+	// boundaries, in milliseconds, of the request latency histogram.
+	MetricsLatencyBuckets string `koanf:"metrics-latency-buckets"`
+	// DisableBuildInfoMetric turns off the headlamp_build_info metric.
+	DisableBuildInfoMetric bool `koanf:"disable-build-info-metric"`
+	// TelemetryExcludeClusterLabel turns off the k8s.cluster.context label on
+	// request traces and metrics.
+	TelemetryExcludeClusterLabel bool `koanf:"telemetry-exclude-cluster-label"`
+	// tempFiles are files generated while parsing, removed by Cleanup.
+	tempFiles []string
+}
+
+func (c *Config) Validate() error {
+	if !c.InCluster && (c.OidcClientID != "" || c.OidcClientSecret != "" || c.OidcIdpIssuerURL != "" ||
+		c.OidcValidatorClientID != "" || c.OidcValidatorIdpIssuerURL != "" || c.OidcIssuerAliases != "" ||
+		c.OidcUserInfoEnabled || c.OidcGroupsFromUserInfo) {
+		return errors.New(`oidc-client-id, oidc-client-secret, oidc-idp-issuer-url, oidc-validator-client-id, 
+		oidc-validator-idp-issuer-url, oidc-issuer-alias, oidc-userinfo-enabled, oidc-groups-from-userinfo, 
+		flags are only meant to be used in inCluster mode`)
+	}
+
+	if c.OidcGroupsFromUserInfo && !c.OidcUserInfoEnabled {
+		return errors.New("oidc-groups-from-userinfo requires oidc-userinfo-enabled")
+	}
+
+	if c.OidcValidatorClientID != "" && len(c.ValidatorClientIDs()) != len(strings.Split(c.OidcValidatorClientID, ",")) {
+		return errors.New("oidc-validator-client-id must not contain empty client IDs")
+	}
+
+	if _, err := c.CookieEncryptionKeyBytes(); err != nil {
+		return err
+	}
+
+	if c.OidcSessionTTL < 0 {
+		return errors.New("oidc-session-ttl needs to be positive, or 0 to follow the token expiry")
+	}
+
+	if c.OidcSessionTTL > 0 && (c.OidcClientID == "" || c.OidcIdpIssuerURL == "") {
+		return errors.New("oidc-session-ttl requires oidc-client-id and oidc-idp-issuer-url")
+	}
+
+	for _, host := range splitCommaList(c.OidcAllowedRedirectHosts) {
+		if !isHostname(host) {
+			return fmt.Errorf("oidc-allowed-redirect-hosts entry %q needs to be a hostname, without scheme or port", host)
+		}
+	}
+
+	if c.OidcTLSServerName != "" && !isHostname(c.OidcTLSServerName) {
+		return fmt.Errorf("oidc-tls-server-name %q needs to be a hostname", c.OidcTLSServerName)
+	}
+
+	if c.OidcTLSServerName != "" && (c.OidcClientID == "" || c.OidcIdpIssuerURL == "") {
+		return errors.New("oidc-tls-server-name requires oidc-client-id and oidc-idp-issuer-url")
+	}
+
+	if c.OidcDiscoveryCacheTTL < 0 {
+		return errors.New("oidc-discovery-cache-ttl needs to be positive, or 0 to fetch the discovery document each time")
+	}
+
+	if c.OidcMaxTokenSize <= 0 {
+		return errors.New("oidc-max-token-size needs to be positive")
+	}
+
+	if err := c.validateSessionStore(); err != nil {
+		return err
+	}
+
+	for _, alias := range c.OidcIssuerAliasList() {
+		if !isAbsoluteURL(alias) {
+			return fmt.Errorf("oidc-issuer-alias %q needs to be an absolute URL", alias)
+		}
+	}
+
+	if err := c.validateDefaultContextFallback(); err != nil {
+		return err
+	}
+
+	if c.BaseURL != "" && !strings.HasPrefix(c.BaseURL, "/") {
+		return errors.New("base-url needs to start with a '/' or be empty")
+	}
+
+	if !isValidCacheControl(c.StaticCacheControl) {
+		return fmt.Errorf("static-cache-control %q is not a valid Cache-Control header value", c.StaticCacheControl)
+	}
+
+	if c.ProxyURLsRefresh < 0 {
+		return errors.New("proxy-urls-refresh needs to be positive, or 0 to read proxy-urls-file only once")
+	}
+
+	if _, err := c.ProxyURLPatterns(); err != nil {
+		return err
+	}
+
+	if c.ProxyURLsFile != "" {
+		if _, err := LoadProxyURLsFile(c.ProxyURLsFile); err != nil {
+			return err
+		}
+	}
+
+	for _, header := range splitCommaList(c.ProxyStripResponseHeaders) {
+		if !headerName.MatchString(header) {
+			return fmt.Errorf("proxy-strip-response-headers: %q is not a valid header name", header)
+		}
+	}
+
+	if _, err := parseProxyAddRequestHeaders(c.ProxyAddRequestHeaders); err != nil {
+		return err
+	}
+
+	if c.AllowPrivateProxyTargets != "" && !c.BlockPrivateProxyTargets {
+		return errors.New("allow-private-proxy-targets requires block-private-proxy-targets")
+	}
+
+	for _, target := range splitCommaList(c.AllowPrivateProxyTargets) {
+		if _, err := parseIPPrefix(target); err != nil {
+			return fmt.Errorf("allow-private-proxy-targets: %q needs to be an IP address or CIDR range", target)
+		}
+	}
+
+	if c.StartupTimeout < 0 {
+		return errors.New("startup-timeout needs to be positive, or 0 for no bound")
+	}
+
+	if c.WatchDrainTimeout < 0 {
+		return errors.New("watch-drain-timeout needs to be positive, or 0 to close watches right away")
+	}
+
+	if c.GlobalRequestTimeout < 0 {
+		return errors.New("global-request-timeout needs to be positive, or 0 for no bound")
+	}
+
+	if c.PerUserRateLimit < 0 {
+		return errors.New("per-user-rate-limit needs to be positive, or 0 to disable rate limiting")
+	}
+
+	if c.PerUserRateBurst < 0 {
+		return errors.New("per-user-rate-burst needs to be positive, or 0 to derive it from per-user-rate-limit")
+	}
+
+	if c.PerUserRateBurst > 0 && c.PerUserRateLimit == 0 {
+		return errors.New("per-user-rate-burst requires per-user-rate-limit")
+	}
+
+	if c.PerUserRateTrustedProxies != "" && c.PerUserRateLimit == 0 {
+		return errors.New("per-user-rate-limit-trusted-proxies requires per-user-rate-limit")
+	}
+
+	for _, proxy := range splitCommaList(c.PerUserRateTrustedProxies) {
+		if _, err := parseIPPrefix(proxy); err != nil {
+			return fmt.Errorf("per-user-rate-limit-trusted-proxies: %q needs to be an IP address or CIDR range", proxy)
+		}
+	}
+
+	if c.AccessLogFormat != AccessLogFormatCombined && c.AccessLogFormat != AccessLogFormatJSON {
+		return fmt.Errorf("access-log-format: unknown format %q, expected %s or %s",
+			c.AccessLogFormat, AccessLogFormatCombined, AccessLogFormatJSON)
+	}
+
+	if c.LogRedactParams != "" && len(c.LogRedactParamList()) != len(strings.Split(c.LogRedactParams, ",")) {
+		return errors.New("log-redact-params must not contain empty parameter names")
+	}
+
+	if c.ListChunkSize < 0 {
+		return errors.New("list-chunk-size needs to be positive, or 0 to disable chunking")
+	}
+
+	if c.APIMaxIdleConns < 0 {
+		return errors.New("api-max-idle-conns needs to be positive, or 0 to keep the client-go default")
+	}
+
+	if c.APIMaxIdleConnsPerHost < 0 {
+		return errors.New("api-max-idle-conns-per-host needs to be positive, or 0 to keep the client-go default")
+	}
+
+	for _, path := range c.MetricsExcludePathList() {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("metrics-exclude-paths entry %q needs to start with a '/'", path)
+		}
+	}
+
+	if _, err := parseLatencyBuckets(c.MetricsLatencyBuckets); err != nil {
+		return err
+	}
+
+	if c.Port < 1 || c.Port > 65535 {
+		return fmt.Errorf("port %d needs to be between 1 and 65535", c.Port)
+	}
+
+	if err := validateListenAddr(c.ListenAddr); err != nil {
+		return err
+	}
+
+	if c.TracingEnabled != nil && *c.TracingEnabled {
+		if c.ServiceName == "" {
+			return errors.New("service-name is required when tracing is enabled")
+		}
+
+		if c.SamplingRate != nil && !(*c.SamplingRate >= 0 && *c.SamplingRate <= 1) {
+			return fmt.Errorf("sampling-rate %v needs to be between 0.0 and 1.0", *c.SamplingRate)
+		}
+
+		if (c.JaegerEndpoint != nil && *c.JaegerEndpoint == "") &&
+			(c.OTLPEndpoint != nil && *c.OTLPEndpoint == "") &&
+			(c.StdoutTraceEnabled != nil && *c.StdoutTraceEnabled) {
+			return errors.New("at least one tracing exporter (jaeger, otlp, or stdout) must be configured")
+		}
+
+		if (c.UseOTLPHTTP != nil && *c.UseOTLPHTTP) &&
+			(c.OTLPEndpoint == nil || *c.OTLPEndpoint == "") {
+			return errors.New("otlp-endpoint must be configured when use-otlp-http is enabled")
+		}
+
+		if (c.OTLPInsecure != nil && *c.OTLPInsecure) &&
+			(c.OTLPEndpoint == nil || *c.OTLPEndpoint == "") {
+			return errors.New("otlp-endpoint must be configured when otlp-insecure is enabled")
+		}
+	}
+
+	return nil
+}
+
+// validateListenAddr checks that listen-addr, if set, is a hostname or an IP
+// address, with IPv6 addresses in brackets as they are followed by the port.
+func validateListenAddr(listenAddr string) error {
+	if listenAddr == "" {
+		return nil
+	}
+
+	if ipv6, ok := strings.CutPrefix(listenAddr, "["); ok {
+		if addr, err := netip.ParseAddr(strings.TrimSuffix(ipv6, "]")); err == nil && addr.Is6() &&
+			strings.HasSuffix(ipv6, "]") {
+			return nil
+		}
+
+		return fmt.Errorf("listen-addr %q is not a valid IPv6 address", listenAddr)
+	}
+
+	if addr, err := netip.ParseAddr(listenAddr); err == nil && addr.Is6() {
+		return fmt.Errorf("listen-addr %q needs to be in brackets, e.g. [%s]", listenAddr, listenAddr)
+	}
+
+	if !isHostname(listenAddr) {
+		return fmt.Errorf("listen-addr %q needs to be a hostname or an IP address", listenAddr)
+	}
+
+	return nil
+}
+
+// CookieEncryptionKeyBytes decodes oidc-cookie-encryption-key, which is a
+// 32 byte key, hex or base64 encoded. It returns nil if no key is set.
+func (c *Config) CookieEncryptionKeyBytes() ([]byte, error) {
+	if c.CookieEncryptionKey == "" {
+		return nil, nil
+	}
+
+	// A hex key never contains the '=' padding of a 32 byte base64 key, so
+	// trying hex first does not shadow valid base64 keys.
+	encoding := "hex"
+
+	key, err := hex.DecodeString(c.CookieEncryptionKey)
+	if err != nil {
+		encoding = "base64"
+
+		key, err = base64.StdEncoding.DecodeString(c.CookieEncryptionKey)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("oidc-cookie-encryption-key is neither hex nor base64 encoded: "+
+			"expected a %d byte key as %d hex or %d base64 characters",
+			cookieEncryptionKeySize, hex.EncodedLen(cookieEncryptionKeySize),
+			base64.StdEncoding.EncodedLen(cookieEncryptionKeySize))
+	}
+
+	if len(key) != cookieEncryptionKeySize {
+		encodedLen := hex.EncodedLen(cookieEncryptionKeySize)
+		if encoding == "base64" {
+			encodedLen = base64.StdEncoding.EncodedLen(cookieEncryptionKeySize)
+		}
+
+		return nil, fmt.Errorf("oidc-cookie-encryption-key decodes from %s to %d bytes, "+
+			"expected exactly %d bytes (%d %s characters)",
+			encoding, len(key), cookieEncryptionKeySize, encodedLen, encoding)
+	}
+
+	return key, nil
+}
+
+// loadCookieEncryptionKey reads oidc-cookie-encryption-key from
+// oidc-cookie-encryption-key-file if set. Otherwise, when OIDC is used without
+// a key, it generates one that only lasts until the server restarts.
+func loadCookieEncryptionKey(config *Config) error {
+	if config.CookieEncryptionKeyFile != "" {
+		if config.CookieEncryptionKey != "" {
+			return errors.New("oidc-cookie-encryption-key and oidc-cookie-encryption-key-file cannot be used together")
+		}
+
+		data, err := os.ReadFile(config.CookieEncryptionKeyFile)
+		if err != nil {
+			return fmt.Errorf("reading oidc-cookie-encryption-key-file: %w", err)
+		}
+
+		config.CookieEncryptionKey = strings.TrimSpace(string(data))
+
+		return nil
+	}
+
+	if config.CookieEncryptionKey != "" || config.OidcClientID == "" {
+		return nil
+	}
+
+	key := make([]byte, cookieEncryptionKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return fmt.Errorf("generating cookie encryption key: %w", err)
+	}
+
+	config.CookieEncryptionKey = base64.StdEncoding.EncodeToString(key)
+
+	logger.Log(logger.LevelWarn, nil, nil,
+		"oidc-cookie-encryption-key is not set, using an ephemeral key: logins in progress will not survive restarts")
+
+	return nil
+}
+
+// validateSessionStore checks that session-store is known and, for the file
+// store, that session-store-dir is set and can be created and written to.
+// It creates nothing, the directory is created when the store is opened.
+func (c *Config) validateSessionStore() error {
+	switch c.SessionStore {
+	case SessionStoreMemory:
+		return nil
+	case SessionStoreFile:
+	default:
+		return fmt.Errorf("session-store: unknown store %q, expected %s or %s",
+			c.SessionStore, SessionStoreMemory, SessionStoreFile)
+	}
+
+	if c.SessionStoreDir == "" {
+		return errors.New("session-store-dir is required when session-store is file")
+	}
+
+	if err := checkDirUsable(c.SessionStoreDir); err != nil {
+		return fmt.Errorf("session-store-dir: %w", err)
+	}
+
+	return nil
+}
+
+// validateDefaultContextFallback checks that default-context-fallback is
+// first, none, or the name of a context that is not skipped.
+func (c *Config) validateDefaultContextFallback() error {
+	fallback := c.DefaultContextFallback
+
+	if strings.TrimSpace(fallback) == "" {
+		return errors.New("default-context-fallback needs to be first, none or a context name")
+	}
+
+	if strings.TrimSpace(fallback) != fallback {
+		return fmt.Errorf("default-context-fallback %q must not start or end with spaces", fallback)
+	}
+
+	if fallback == DefaultContextFallbackFirst || fallback == DefaultContextFallbackNone {
+		return nil
+	}
+
+	if c.IsContextSkipped(fallback) {
+		return fmt.Errorf("default-context-fallback %q is listed in skipped-kube-contexts", fallback)
+	}
+
+	return nil
+}
+
+// SkippedContexts returns the set of kubeconfig context names listed in
+// skipped-kube-contexts.
+func (c *Config) SkippedContexts() map[string]struct{} {
+	skipped := map[string]struct{}{}
+
+	for _, name := range splitCommaList(c.SkippedKubeContexts) {
+		skipped[name] = struct{}{}
+	}
+
+	return skipped
+}
+
+// IsContextSkipped returns true if name exactly matches one of the
+// skipped-kube-contexts.
+func (c *Config) IsContextSkipped(name string) bool {
+	_, ok := c.SkippedContexts()[name]
+
+	return ok
+}
+
+// LogRedactParamList returns the query parameters whose values are replaced
+// in access logs and traces.
+func (c *Config) LogRedactParamList() []string {
+	return splitCommaList(c.LogRedactParams)
+}
+
+// OidcAllowedRedirectHostList returns the hosts the OIDC login may redirect
+// to. When empty, the login redirects to the host of the request, which is
+// where Headlamp is served from, e.g. its ingress.
+func (c *Config) OidcAllowedRedirectHostList() []string {
+	return splitCommaList(c.OidcAllowedRedirectHosts)
+}
+
+// hostnameLabels matches a DNS hostname of RFC 1123.
+var hostnameLabels = regexp.MustCompile(
+	`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?)*$`)
+
+// isHostname returns true if host is a DNS hostname or an IP address.
+func isHostname(host string) bool {
+	if _, err := netip.ParseAddr(host); err == nil {
+		return true
+	}
+
+	return len(host) <= 253 && hostnameLabels.MatchString(host)
+}
+
+// ValidatorClientIDs returns the client IDs accepted in the aud claim of
+// OIDC tokens, from the comma separated oidc-validator-client-id.
+func (c *Config) ValidatorClientIDs() []string {
+	return splitCommaList(c.OidcValidatorClientID)
+}
+
+// EffectiveValidatorIssuer returns the issuer expected in the iss claim of
+// OIDC tokens: oidc-validator-idp-issuer-url if set, else oidc-idp-issuer-url.
+func (c *Config) EffectiveValidatorIssuer() string {
+	if c.OidcValidatorIdpIssuerURL != "" {
+		return c.OidcValidatorIdpIssuerURL
+	}
+
+	return c.OidcIdpIssuerURL
+}
+
+// EffectiveValidatorClientID returns the client ID expected in the aud claim
+// of OIDC tokens: the first oidc-validator-client-id if set, else
+// oidc-client-id. Use ValidatorClientIDs for all the accepted client IDs.
+func (c *Config) EffectiveValidatorClientID() string {
+	if clientIDs := c.ValidatorClientIDs(); len(clientIDs) > 0 {
+		return clientIDs[0]
+	}
+
+	return c.OidcClientID
+}
+
+// EffectivePerUserRateBurst returns the number of requests a user can make
+// at once before per-user-rate-limit applies: per-user-rate-burst if set,
+// else the rate limit rounded up.
+func (c *Config) EffectivePerUserRateBurst() int {
+	if c.PerUserRateBurst > 0 {
+		return c.PerUserRateBurst
+	}
+
+	return int(math.Ceil(c.PerUserRateLimit))
+}
+
+// oidcScopeRequirement is a scope an optional OIDC feature needs from the provider.
+type oidcScopeRequirement struct {
+	scope   string
+	feature string
+	enabled func(c *Config) bool
+}
+
+// requiredOidcScopes lists the scopes EffectiveOidcScopes adds on top of
+// oidc-scopes for each enabled feature.
+var requiredOidcScopes = []oidcScopeRequirement{
+	{
+		// The tokens of OIDC logins are refreshed before they expire, which
+		// needs the provider to issue a refresh token.
+		scope:   "offline_access",
+		feature: "token refresh",
+		enabled: func(c *Config) bool { return c.OidcClientID != "" },
+	},
+	{
+		scope:   "profile",
+		feature: "oidc-userinfo-enabled",
+		enabled: func(c *Config) bool { return c.OidcUserInfoEnabled },
+	},
+	{
+		scope:   "groups",
+		feature: "oidc-groups-from-userinfo",
+		enabled: func(c *Config) bool { return c.OidcUserInfoEnabled && c.OidcGroupsFromUserInfo },
+	},
+}
+
+// EffectiveOidcScopes returns the configured oidc-scopes, trimmed and without
+// duplicates, plus the scopes required by the enabled OIDC features.
+func (c *Config) EffectiveOidcScopes() []string {
+	scopes := []string{}
+	seen := map[string]bool{}
+
+	for _, scope := range splitCommaList(c.OidcScopes) {
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+
+	for _, required := range requiredOidcScopes {
+		if seen[required.scope] || !required.enabled(c) {
+			continue
+		}
+
+		seen[required.scope] = true
+		scopes = append(scopes, required.scope)
+
+		logger.Log(logger.LevelInfo, map[string]string{"scope": required.scope, "feature": required.feature},
+			nil, "adding OIDC scope required by feature")
+	}
+
+	return scopes
+}
+
+// OidcIssuerAliasList returns the alternate issuer values accepted in the
+// iss claim of OIDC tokens, in addition to the configured issuer.
+func (c *Config) OidcIssuerAliasList() []string {
+	return splitCommaList(c.OidcIssuerAliases)
+}
+
+// ProxyStripResponseHeaderList returns the canonical names of the headers
+// removed from the responses of the cluster proxy.
+func (c *Config) ProxyStripResponseHeaderList() []string {
+	headers := splitCommaList(c.ProxyStripResponseHeaders)
+	for i, header := range headers {
+		headers[i] = http.CanonicalHeaderKey(header)
+	}
+
+	return headers
+}
+
+// ProxyAddRequestHeaderMap returns the headers set on the requests of the
+// proxies, by canonical header name.
+func (c *Config) ProxyAddRequestHeaderMap() map[string]string {
+	headers, _ := parseProxyAddRequestHeaders(c.ProxyAddRequestHeaders)
+
+	return headers
+}
+
+// parseProxyAddRequestHeaders parses a proxy-add-request-headers value, a
+// comma separated list of name=value pairs.
+func parseProxyAddRequestHeaders(value string) (map[string]string, error) {
+	headers := map[string]string{}
+
+	for _, entry := range splitCommaList(value) {
+		name, headerValue, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("proxy-add-request-headers: %q needs to be in the name=value format", entry)
+		}
+
+		name = strings.TrimSpace(name)
+		if !headerName.MatchString(name) {
+			return nil, fmt.Errorf("proxy-add-request-headers: %q is not a valid header name", name)
+		}
+
+		if strings.ContainsAny(headerValue, "\r\n\x00") {
+			return nil, fmt.Errorf("proxy-add-request-headers: the value of %s contains invalid characters", name)
+		}
+
+		headers[http.CanonicalHeaderKey(name)] = strings.TrimSpace(headerValue)
+	}
+
+	return headers, nil
+}
+
+// PerUserRateTrustedProxyList returns the IP ranges of the proxies, like an
+// ingress controller, whose X-Forwarded-For header gives the client IP that
+// per-user-rate-limit falls back to.
+func (c *Config) PerUserRateTrustedProxyList() []netip.Prefix {
+	var prefixes []netip.Prefix
+
+	for _, proxy := range splitCommaList(c.PerUserRateTrustedProxies) {
+		if prefix, err := parseIPPrefix(proxy); err == nil {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	return prefixes
+}
+
+// AllowPrivateProxyTargetList returns the private IP ranges the external
+// proxy may still connect to when block-private-proxy-targets is set.
+func (c *Config) AllowPrivateProxyTargetList() []netip.Prefix {
+	var prefixes []netip.Prefix
+
+	for _, target := range splitCommaList(c.AllowPrivateProxyTargets) {
+		if prefix, err := parseIPPrefix(target); err == nil {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	return prefixes
+}
+
+// parseIPPrefix parses a CIDR range, or an IP address as a single address range.
+func parseIPPrefix(value string) (netip.Prefix, error) {
+	if addr, err := netip.ParseAddr(value); err == nil {
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+
+	prefix, err := netip.ParsePrefix(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	return prefix.Masked(), nil
+}
+
+// OTLPExportEndpoint returns otlp-endpoint without its scheme, and whether
+// to export over plaintext. TLS is only used for an https:// endpoint
+// without otlp-insecure, so host:port and http:// endpoints stay plaintext.
+func (c *Config) OTLPExportEndpoint() (string, bool) {
+	if c.OTLPEndpoint == nil {
+		return "", true
+	}
+
+	if endpoint, ok := strings.CutPrefix(*c.OTLPEndpoint, "https://"); ok {
+		return endpoint, c.OTLPInsecure != nil && *c.OTLPInsecure
+	}
+
+	return strings.TrimPrefix(*c.OTLPEndpoint, "http://"), true
+}
+
+// MetricsExcludePathList returns the paths excluded from request metrics.
+func (c *Config) MetricsExcludePathList() []string {
+	return splitCommaList(c.MetricsExcludePaths)
+}
+
+// MetricsLatencyBucketList returns the bucket boundaries of the request
+// latency histogram, or nil to keep the default ones.
+func (c *Config) MetricsLatencyBucketList() []float64 {
+	buckets, err := parseLatencyBuckets(c.MetricsLatencyBuckets)
+	if err != nil {
+		return nil
+	}
+
+	return buckets
+}
+
+// parseLatencyBuckets parses a comma separated list of histogram bucket
+// boundaries, which must be positive and strictly ascending.
+func parseLatencyBuckets(value string) ([]float64, error) {
+	var buckets []float64
+
+	for _, entry := range splitCommaList(value) {
+		bucket, err := strconv.ParseFloat(entry, 64)
+		if err != nil || math.IsNaN(bucket) || math.IsInf(bucket, 0) {
+			return nil, fmt.Errorf("metrics-latency-buckets entry %q needs to be a number", entry)
+		}
+
+		if bucket <= 0 {
+			return nil, fmt.Errorf("metrics-latency-buckets entry %q needs to be positive", entry)
+		}
+
+		if len(buckets) > 0 && bucket <= buckets[len(buckets)-1] {
+			return nil, fmt.Errorf("metrics-latency-buckets entry %q needs to be larger than the previous one", entry)
+		}
+
+		buckets = append(buckets, bucket)
+	}
+
+	return buckets, nil
+}
+
+// parseConfigPrecedence parses a config-precedence value, which must list
+// each config source exactly once, from the highest to the lowest priority.
+func parseConfigPrecedence(value string) ([]string, error) {
+	sources := splitCommaList(value)
+	seen := map[string]bool{}
+
+	for _, source := range sources {
+		switch source {
+		case configSourceFlag, configSourceEnv, configSourceFile:
+		default:
+			return nil, fmt.Errorf("config-precedence: unknown source %q, expected flag, env or file", source)
+		}
+
+		if seen[source] {
+			return nil, fmt.Errorf("config-precedence: source %q is listed more than once", source)
+		}
+
+		seen[source] = true
+	}
+
+	if len(seen) != 3 {
+		return nil, fmt.Errorf("config-precedence: %q needs to list each of flag, env and file once", value)
+	}
+
+	return sources, nil
+}
+
+// unknownConfigKeys returns the keys that match neither a flag name nor the
+// koanf tag of a Config field, as some fields like jaeger-endpoint have no flag.
+func unknownConfigKeys(f *flag.FlagSet, keys []string) []string {
+	var unknown []string
+
+	configType := reflect.TypeFor[Config]()
+	tags := make(map[string]struct{}, configType.NumField())
+
+	for i := range configType.NumField() {
+		if tag := configType.Field(i).Tag.Get("koanf"); tag != "" {
+			tags[tag] = struct{}{}
+		}
+	}
+
+	for _, key := range keys {
+		if _, ok := tags[key]; !ok && f.Lookup(key) == nil {
+			unknown = append(unknown, key)
+		}
+	}
+
+	return unknown
+}
+
+// lookupBootstrapValue returns the value of a flag needed before the config
+// sources are loaded: the command line value if set, else the value in env,
+// the values read by Loader.Env, else the flag default.
+func lookupBootstrapValue(f *flag.FlagSet, explicitFlags map[string]bool, env map[string]interface{},
+	name string,
+) string {
+	if explicitFlags[name] {
+		return f.Lookup(name).Value.String()
+	}
+
+	if value, ok := env[name]; ok {
+		return fmt.Sprint(value)
+	}
+
+	return f.Lookup(name).DefValue
+}
+
+// proxyURLPatternParts splits a proxy URL pattern into its scheme, host and
+// path.
+var proxyURLPatternParts = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9+.-]*)://([^/]+)(.*)$`)
+
+// proxyURLPattern is a parsed proxy URL pattern. The scheme is matched
+// exactly, while the host and path are globs whose wildcards do not cross
+// '.' and '/' respectively, so a pattern cannot match a target whose host
+// only appears in its path, query or fragment.
+type proxyURLPattern struct {
+	scheme string
+	host   glob.Glob
+	path   glob.Glob
+}
+
+// parseProxyURLPattern parses pattern, an absolute URL optionally using glob
+// wildcards such as https://*.example.com/*. A trailing "/*" matches any path
+// below, as "/**" does.
+func parseProxyURLPattern(pattern string) (*proxyURLPattern, error) {
+	parts := proxyURLPatternParts.FindStringSubmatch(pattern)
+	if parts == nil {
+		return nil, fmt.Errorf("proxy URL %q needs to be an absolute URL", pattern)
+	}
+
+	host, err := glob.Compile(strings.ToLower(parts[2]), '.')
+	if err != nil {
+		return nil, fmt.Errorf("proxy URL %q is not a valid pattern: %w", pattern, err)
+	}
+
+	pathPattern := parts[3]
+	if strings.HasSuffix(pathPattern, "/*") {
+		pathPattern += "*"
+	}
+
+	path, err := glob.Compile(proxyURLPath(pathPattern), '/')
+	if err != nil {
+		return nil, fmt.Errorf("proxy URL %q is not a valid pattern: %w", pattern, err)
+	}
+
+	return &proxyURLPattern{scheme: strings.ToLower(parts[1]), host: host, path: path}, nil
 }
 
-func (c *Config) Validate() error {
-	if !c.InCluster && (c.OidcClientID != "" || c.OidcClientSecret != "" || c.OidcIdpIssuerURL != "" ||
-		c.OidcValidatorClientID != "" || c.OidcValidatorIdpIssuerURL != "") {
-		return errors.New(`oidc-client-id, oidc-client-secret, oidc-idp-issuer-url, oidc-validator-client-id, 
-		oidc-validator-idp-issuer-url, flags are only meant to be used in inCluster mode`)
+// proxyURLPath returns path, or "/" if it is empty, so that a pattern without
+// a path matches the root of its host.
+func proxyURLPath(path string) string {
+	if path == "" {
+		return "/"
 	}
 
-	if c.BaseURL != "" && !strings.HasPrefix(c.BaseURL, "/") {
-		return errors.New("base-url needs to start with a '/' or be empty")
+	return path
+}
+
+// match returns true if target matches the pattern. Only the scheme, host
+// and path of target are matched; its query and fragment are ignored.
+func (p *proxyURLPattern) match(target *url.URL) bool {
+	return target.Scheme == p.scheme &&
+		target.User == nil &&
+		p.host.Match(strings.ToLower(target.Host)) &&
+		p.path.Match(proxyURLPath(target.EscapedPath()))
+}
+
+// validateProxyURLPattern checks that pattern is an absolute URL, optionally
+// using glob wildcards such as https://*.example.com/*.
+func validateProxyURLPattern(pattern string) error {
+	_, err := parseProxyURLPattern(pattern)
+
+	return err
+}
+
+// ProxyURLPatterns returns the validated proxy-urls patterns, without empty
+// entries and duplicates.
+func (c *Config) ProxyURLPatterns() ([]string, error) {
+	var patterns []string
+
+	for _, pattern := range splitCommaList(c.ProxyURLs) {
+		if err := validateProxyURLPattern(pattern); err != nil {
+			return nil, fmt.Errorf("proxy-urls: %w", err)
+		}
+
+		if !slices.Contains(patterns, pattern) {
+			patterns = append(patterns, pattern)
+		}
 	}
 
-	if c.TracingEnabled != nil && *c.TracingEnabled {
-		if c.ServiceName == "" {
-			return errors.New("service-name is required when tracing is enabled")
+	return patterns, nil
+}
+
+// IsProxyAllowed returns true if target matches one of the proxy-urls patterns.
+func (c *Config) IsProxyAllowed(target string) bool {
+	patterns, err := c.ProxyURLPatterns()
+	if err != nil {
+		return false
+	}
+
+	return MatchProxyURL(patterns, target)
+}
+
+// MatchProxyURL returns true if target matches one of the proxy URL patterns.
+// Invalid patterns and targets never match.
+func MatchProxyURL(patterns []string, target string) bool {
+	targetURL, err := url.Parse(target)
+	if err != nil || !targetURL.IsAbs() {
+		return false
+	}
+
+	for _, pattern := range patterns {
+		p, err := parseProxyURLPattern(pattern)
+		if err != nil {
+			continue
 		}
 
-		if (c.JaegerEndpoint != nil && *c.JaegerEndpoint == "") &&
-			(c.OTLPEndpoint != nil && *c.OTLPEndpoint == "") &&
-			(c.StdoutTraceEnabled != nil && *c.StdoutTraceEnabled) {
-			return errors.New("at least one tracing exporter (jaeger, otlp, or stdout) must be configured")
+		if p.match(targetURL) {
+			return true
 		}
+	}
 
-		if (c.UseOTLPHTTP != nil && *c.UseOTLPHTTP) &&
-			(c.OTLPEndpoint == nil || *c.OTLPEndpoint == "") {
-			return errors.New("otlp-endpoint must be configured when use-otlp-http is enabled")
+	return false
+}
+
+// LoadProxyURLsFile reads the allowed proxy URLs from a proxy-urls-file.
+// Entries are separated by new lines or commas; empty lines and lines
+// starting with '#' are ignored. Every entry is validated.
+func LoadProxyURLsFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading proxy-urls-file: %w", err)
+	}
+
+	proxyURLs := []string{}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		for _, proxyURL := range splitCommaList(line) {
+			if err := validateProxyURLPattern(proxyURL); err != nil {
+				return nil, fmt.Errorf("proxy-urls-file %s: %w", path, err)
+			}
+
+			proxyURLs = append(proxyURLs, proxyURL)
+		}
+	}
+
+	return proxyURLs, nil
+}
+
+// headerName matches a valid HTTP header name, which is a token in RFC 7230.
+var headerName = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+// cacheControlDirective matches a single Cache-Control directive, e.g.
+// "public", "max-age=3600" or `no-cache="Set-Cookie"`.
+var cacheControlDirective = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]*(=([0-9A-Za-z-]+|"[^"]*"))?$`)
+
+// isValidCacheControl returns true if value is empty or a comma separated
+// list of Cache-Control directives.
+func isValidCacheControl(value string) bool {
+	if value == "" {
+		return true
+	}
+
+	for _, directive := range strings.Split(value, ",") {
+		if !cacheControlDirective.MatchString(strings.TrimSpace(directive)) {
+			return false
 		}
 	}
 
+	return true
+}
+
+// CheckTempDir returns an error if temp-dir is not an existing directory
+// files can be created in. As it creates and removes a file, it is meant to
+// be called at startup rather than from Validate.
+func (c *Config) CheckTempDir() error {
+	if err := checkDirWritable(c.TempDir); err != nil {
+		return fmt.Errorf("temp-dir: %w", err)
+	}
+
 	return nil
 }
 
-// Parse Loads the config from flags and env.
+// checkDirWritable returns an error if dir is not an existing directory
+// files can be created in.
+func checkDirWritable(dir string) error {
+	file, err := os.CreateTemp(dir, ".headlamp-write-check-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %w", dir, err)
+	}
+
+	file.Close()
+
+	return os.Remove(file.Name())
+}
+
+// checkDirUsable returns an error if dir, or its closest existing parent when
+// dir is yet to be created, is not a directory files can be created in.
+// Unlike checkDirWritable, it creates nothing.
+func checkDirUsable(dir string) error {
+	path := filepath.Clean(dir)
+
+	for {
+		info, err := os.Stat(path)
+		if errors.Is(err, fs.ErrNotExist) && filepath.Dir(path) != path {
+			path = filepath.Dir(path)
+
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("checking %s: %w", dir, err)
+		}
+
+		if !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", path)
+		}
+
+		if !isDirWritable(path, info) {
+			return fmt.Errorf("%s is not writable", path)
+		}
+
+		return nil
+	}
+}
+
+// splitCommaList splits a comma separated flag value, trimming whitespace
+// around each entry and dropping empty ones.
+func splitCommaList(value string) []string {
+	var entries []string
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// isAbsoluteURL returns true if value parses as a URL with a scheme and host.
+func isAbsoluteURL(value string) bool {
+	u, err := url.Parse(value)
+
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// Parse Loads the config from flags, env and the optional config-file.
 // env vars should start with HEADLAMP_CONFIG_ and use _ as separator
 // If a value is set both in flags and env then flag takes priority.
 // eg:
 // export HEADLAMP_CONFIG_PORT=2344
 // go run ./cmd --port=3456
 // the value of port will be 3456.
+// The config-file is a YAML file using the flag names as keys, and has the
+// lowest priority. The order can be changed with config-precedence.
 
-//nolint:funlen
 func Parse(args []string) (*Config, error) {
-	var config Config
+	return ParseWithLoader(args, KoanfLoader{})
+}
 
+// ParseWithLoader is Parse, reading the config sources with loader instead
+// of KoanfLoader, e.g. to use fake sources in tests, or to add one.
+//
+//nolint:funlen
+func ParseWithLoader(args []string, loader Loader) (*Config, error) {
 	f := flagset()
 
 	k := koanf.New(".")
@@ -107,7 +1166,7 @@ func Parse(args []string) (*Config, error) {
 	}
 
 	// First Load default args from flags
-	if err := k.Load(basicflag.Provider(f, "."), nil); err != nil {
+	if err := loadValues(k, func() (map[string]interface{}, error) { return loader.Defaults(f) }); err != nil {
 		logger.Log(logger.LevelError, nil, err, "loading default config from flags")
 
 		return nil, fmt.Errorf("error loading default config from flags: %w", err)
@@ -127,31 +1186,121 @@ func Parse(args []string) (*Config, error) {
 		explicitFlags[f.Name] = true
 	})
 
-	// Load config from env
-	if err := k.Load(env.Provider("HEADLAMP_CONFIG_", ".", func(s string) string {
-		return strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(s, "HEADLAMP_CONFIG_")), "_", "-")
-	}), nil); err != nil {
+	env, err := loader.Env()
+	if err != nil {
 		logger.Log(logger.LevelError, nil, err, "loading config from env")
 
 		return nil, fmt.Errorf("error loading config from env: %w", err)
 	}
 
-	// Load only the flags that were set
-	if err := k.Load(basicflag.ProviderWithValue(f, ".", func(key string, value string) (string, interface{}) {
-		flagSet := false
-		f.Visit(func(f *flag.Flag) {
-			if f.Name == key {
-				flagSet = true
+	// The precedence and config file decide how the other sources are loaded,
+	// so they are resolved up front from the flags or env.
+	precedence, err := parseConfigPrecedence(lookupBootstrapValue(f, explicitFlags, env, "config-precedence"))
+	if err != nil {
+		logger.Log(logger.LevelError, nil, err, "parsing config precedence")
+
+		return nil, err
+	}
+
+	configFile := lookupBootstrapValue(f, explicitFlags, env, "config-file")
+
+	loaders := map[string]func() error{
+		configSourceFile: func() error {
+			if configFile == "" {
+				return nil
+			}
+
+			fileK := koanf.New(".")
+
+			if err := loadValues(fileK, func() (map[string]interface{}, error) { return loader.File(configFile) }); err != nil {
+				logger.Log(logger.LevelError, nil, err, "loading config from file")
+
+				return fmt.Errorf("error loading config from file: %w", err)
+			}
+
+			if unknown := unknownConfigKeys(f, fileK.Keys()); len(unknown) > 0 {
+				err := fmt.Errorf("unknown keys in config file %s: %s", configFile, strings.Join(unknown, ", "))
+				logger.Log(logger.LevelError, nil, err, "loading config from file")
+
+				return err
+			}
+
+			return k.Merge(fileK)
+		},
+		configSourceEnv: func() error {
+			return loadValues(k, func() (map[string]interface{}, error) { return env, nil })
+		},
+		configSourceFlag: func() error {
+			// Load only the flags that were set
+			if err := loadValues(k, func() (map[string]interface{}, error) {
+				return loader.Flags(f, explicitFlags)
+			}); err != nil {
+				logger.Log(logger.LevelError, nil, err, "loading config from flags")
+
+				return fmt.Errorf("error loading config from flags: %w", err)
 			}
-		})
-		if flagSet {
-			return key, value
+
+			return nil
+		},
+	}
+
+	// Load the sources from the lowest to the highest priority, so later
+	// ones override earlier ones.
+	for i := len(precedence) - 1; i >= 0; i-- {
+		if err := loaders[precedence[i]](); err != nil {
+			return nil, err
 		}
-		return "", nil
-	}), nil); err != nil {
-		logger.Log(logger.LevelError, nil, err, "loading config from flags")
+	}
+
+	return newConfigFrom(k, f, explicitFlags, loader.KubeConfigEnv())
+}
+
+// NewConfig builds a Config for programs embedding the backend, from the
+// flag defaults and overrides, which use the flag names as keys, e.g.
+// {"port": 4466, "in-cluster": true}. Unlike Parse, it reads no command line,
+// env vars or config file, so the kubeconfig path defaults to ~/.kube/config
+// unless the "kubeconfig" key is set. The result is validated like in Parse.
+func NewConfig(overrides map[string]interface{}) (*Config, error) {
+	f := flagset()
+
+	k := koanf.New(".")
+
+	if err := loadValues(k, func() (map[string]interface{}, error) { return KoanfLoader{}.Defaults(f) }); err != nil {
+		return nil, fmt.Errorf("error loading default config from flags: %w", err)
+	}
+
+	keys := slices.Sorted(maps.Keys(overrides))
+	if unknown := unknownConfigKeys(f, keys); len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown config keys: %s", strings.Join(unknown, ", "))
+	}
+
+	explicitFlags := make(map[string]bool, len(keys))
+
+	for _, key := range keys {
+		explicitFlags[key] = true
+	}
+
+	if err := loadValues(k, func() (map[string]interface{}, error) { return overrides, nil }); err != nil {
+		return nil, fmt.Errorf("error loading config overrides: %w", err)
+	}
+
+	return newConfigFrom(k, f, explicitFlags, "")
+}
+
+// newConfigFrom decodes the values loaded into k, checks them and resolves the
+// kubeconfig path, using kubeConfigEnv like the KUBECONFIG env var. It is the
+// part shared by ParseWithLoader and NewConfig.
+//
+//nolint:funlen
+func newConfigFrom(k *koanf.Koanf, f *flag.FlagSet, explicitFlags map[string]bool,
+	kubeConfigEnv string,
+) (*Config, error) {
+	var config Config
 
-		return nil, fmt.Errorf("error loading config from flags: %w", err)
+	if err := normalizeDurations(k, f); err != nil {
+		logger.Log(logger.LevelError, nil, err, "parsing durations")
+
+		return nil, err
 	}
 
 	if err := k.Unmarshal("", &config); err != nil {
@@ -166,6 +1315,47 @@ func Parse(args []string) (*Config, error) {
 		config.WatchPluginsChanges = false
 	}
 
+	applyDisableDevEndpoints(&config)
+
+	// disable-in-cluster-detection only guards the automatic choice of
+	// in-cluster mode, so it has no effect when in-cluster is set explicitly.
+	if config.DisableInClusterDetection && explicitFlags["in-cluster"] {
+		logger.Log(logger.LevelWarn, nil, nil,
+			"disable-in-cluster-detection has no effect when in-cluster is set explicitly")
+	}
+
+	if config.DisableBuildInfoMetric && (config.MetricsEnabled == nil || !*config.MetricsEnabled) {
+		logger.Log(logger.LevelWarn, nil, nil, "disable-build-info-metric has no effect unless metrics-enabled is set")
+	}
+
+	if config.TelemetryExcludeClusterLabel && (config.MetricsEnabled == nil || !*config.MetricsEnabled) &&
+		(config.TracingEnabled == nil || !*config.TracingEnabled) {
+		logger.Log(logger.LevelWarn, nil, nil,
+			"telemetry-exclude-cluster-label has no effect unless metrics-enabled or tracing-enabled is set")
+	}
+
+	if config.MetricsLatencyBuckets != "" && (config.MetricsEnabled == nil || !*config.MetricsEnabled) {
+		logger.Log(logger.LevelWarn, nil, nil, "metrics-latency-buckets has no effect unless metrics-enabled is set")
+	}
+
+	if explicitFlags["access-log-format"] && !config.AccessLog {
+		logger.Log(logger.LevelWarn, nil, nil, "access-log-format has no effect unless access-log is set")
+	}
+
+	if explicitFlags["oidc-max-token-size"] && config.OidcClientID == "" {
+		logger.Log(logger.LevelWarn, nil, nil, "oidc-max-token-size has no effect unless oidc-client-id is set")
+	}
+
+	if config.OidcDiscoveryCacheTTL > 0 && config.OidcClientID == "" {
+		logger.Log(logger.LevelWarn, nil, nil, "oidc-discovery-cache-ttl has no effect unless oidc-client-id is set")
+	}
+
+	if err := loadCookieEncryptionKey(&config); err != nil {
+		logger.Log(logger.LevelError, nil, err, "loading cookie encryption key")
+
+		return nil, err
+	}
+
 	// Validate parsed config
 	if err := config.Validate(); err != nil {
 		logger.Log(logger.LevelError, nil, err, "validating config")
@@ -173,24 +1363,228 @@ func Parse(args []string) (*Config, error) {
 		return nil, err
 	}
 
-	kubeConfigPath := ""
+	if err := writeKubeConfigBase64(&config); err != nil {
+		logger.Log(logger.LevelError, nil, err, "loading kubeconfig-base64")
+
+		_ = config.Cleanup()
+
+		return nil, err
+	}
 
 	// If we don't have a specified kubeConfig path, and we are not running
 	// in-cluster, then use the default path.
+	if config.KubeConfigPath == "" && !config.InCluster {
+		config.KubeConfigPath = kubeConfigEnv
+		if config.KubeConfigPath == "" {
+			defaultPath, err := defaultKubeConfigPath()
+			if err != nil {
+				logger.Log(logger.LevelError, nil, err, "getting the default kubeconfig path")
+
+				_ = config.Cleanup()
+
+				return nil, err
+			}
+
+			config.KubeConfigPath = defaultPath
+		}
+	}
+
+	return &config, nil
+}
+
+// KubeConfigPaths returns the paths listed in KubeConfigPath, which like
+// KUBECONFIG can hold several paths joined by the OS path list separator.
+// The paths are made absolute, with a leading ~ expanded to the home
+// directory, and listed once, in their original order.
+func (c *Config) KubeConfigPaths() []string {
+	var paths []string
+
+	for _, path := range filepath.SplitList(c.KubeConfigPath) {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		path = expandHomeDir(path)
+
+		if absPath, err := filepath.Abs(path); err == nil {
+			path = absPath
+		}
+
+		path = filepath.Clean(path)
+
+		if !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+
+	return paths
+}
+
+// expandHomeDir replaces a leading ~ in path with the home directory.
+func expandHomeDir(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		return path
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+
+	return filepath.Join(homeDir, path[1:])
+}
+
+// loadValues loads the values returned by read into k.
+func loadValues(k *koanf.Koanf, read func() (map[string]interface{}, error)) error {
+	values, err := read()
+	if err != nil {
+		return err
+	}
+
+	return k.Load(confmap.Provider(values, "."), nil)
+}
+
+// applyDisableDevEndpoints forces off every debug surface when
+// disable-dev-endpoints is set, regardless of the individual flags.
+func applyDisableDevEndpoints(config *Config) {
+	if !config.DisableDevEndpoints {
+		return
+	}
+
+	if config.DevMode {
+		config.DevMode = false
+
+		logger.Log(logger.LevelWarn, map[string]string{"flag": "dev"}, nil,
+			"debug surface disabled by disable-dev-endpoints")
+	}
+}
+
+// kubeConfigFile holds the fields used to check that some content is a
+// kubeconfig and to find its contexts.
+type kubeConfigFile struct {
+	Kind           string              `json:"kind"`
+	CurrentContext string              `json:"current-context"`
+	Clusters       []interface{}       `json:"clusters"`
+	Contexts       []kubeConfigContext `json:"contexts"`
+}
+
+// kubeConfigContext is a context entry of a kubeconfig.
+type kubeConfigContext struct {
+	Name string `json:"name"`
+}
+
+// DefaultContext returns the context that is active by default. Like kubectl,
+// this is the first current-context set in the kubeconfig paths, except that a
+// current-context listed in skipped-kube-contexts is passed over, as it is not
+// loaded. Without one, default-context-fallback picks one of the contexts not
+// skipped. It returns an empty string if there is none.
+func (c *Config) DefaultContext() (string, error) {
+	var names []string
+
+	skipped := c.SkippedContexts()
+
+	for _, path := range c.KubeConfigPaths() {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return "", fmt.Errorf("reading kubeconfig: %w", err)
+		}
+
+		var kubeConfig kubeConfigFile
+		if err := yaml.Unmarshal(data, &kubeConfig); err != nil {
+			return "", fmt.Errorf("kubeconfig %s is not valid: %w", path, err)
+		}
+
+		if _, ok := skipped[kubeConfig.CurrentContext]; !ok && kubeConfig.CurrentContext != "" {
+			return kubeConfig.CurrentContext, nil
+		}
+
+		for _, context := range kubeConfig.Contexts {
+			if _, ok := skipped[context.Name]; !ok {
+				names = append(names, context.Name)
+			}
+		}
+	}
+
+	switch c.DefaultContextFallback {
+	case DefaultContextFallbackNone:
+		return "", nil
+	case DefaultContextFallbackFirst:
+		if len(names) == 0 {
+			return "", nil
+		}
+
+		return names[0], nil
+	}
+
+	if !slices.Contains(names, c.DefaultContextFallback) {
+		return "", fmt.Errorf("default-context-fallback: context %q not found in kubeconfig", c.DefaultContextFallback)
+	}
+
+	return c.DefaultContextFallback, nil
+}
+
+// writeKubeConfigBase64 decodes kubeconfig-base64, if set, into a temporary
+// file and points KubeConfigPath to it. The file is removed by Cleanup.
+func writeKubeConfigBase64(config *Config) error {
+	if config.KubeConfigBase64 == "" {
+		return nil
+	}
+
 	if config.KubeConfigPath != "" {
-		kubeConfigPath = config.KubeConfigPath
-	} else if !config.InCluster {
-		kubeConfigEnv := os.Getenv("KUBECONFIG")
-		if kubeConfigEnv != "" {
-			kubeConfigPath = kubeConfigEnv
-		} else {
-			kubeConfigPath = GetDefaultKubeConfigPath()
+		return errors.New("kubeconfig and kubeconfig-base64 cannot be used together")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(config.KubeConfigBase64))
+	if err != nil {
+		return fmt.Errorf("decoding kubeconfig-base64: %w", err)
+	}
+
+	var kubeConfig kubeConfigFile
+	if err := yaml.Unmarshal(data, &kubeConfig); err != nil {
+		return fmt.Errorf("kubeconfig-base64 is not a valid kubeconfig: %w", err)
+	}
+
+	if (kubeConfig.Kind != "" && kubeConfig.Kind != "Config") || len(kubeConfig.Contexts) == 0 {
+		return errors.New("kubeconfig-base64 is not a valid kubeconfig: no contexts found")
+	}
+
+	file, err := os.CreateTemp(config.TempDir, "headlamp-kubeconfig-*")
+	if err != nil {
+		return fmt.Errorf("creating kubeconfig file: %w", err)
+	}
+
+	defer file.Close()
+
+	config.tempFiles = append(config.tempFiles, file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("writing kubeconfig file: %w", err)
+	}
+
+	config.KubeConfigPath = file.Name()
+
+	return nil
+}
+
+// Cleanup removes the temporary files generated while parsing the config,
+// such as the kubeconfig decoded from kubeconfig-base64.
+func (c *Config) Cleanup() error {
+	var errs []error
+
+	for _, file := range c.tempFiles {
+		if err := os.Remove(file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
 		}
 	}
 
-	config.KubeConfigPath = kubeConfigPath
+	c.tempFiles = nil
 
-	return &config, nil
+	return errors.Join(errs...)
 }
 
 // MakeHeadlampKubeConfigsDir returns the default directory to store kubeconfig
@@ -236,39 +1630,114 @@ func DefaultHeadlampKubeConfigFile() (string, error) {
 func flagset() *flag.FlagSet {
 	f := flag.NewFlagSet("config", flag.ContinueOnError)
 
+	f.String("config-file", "", "Path to a YAML config file, using the flag names as keys")
+	f.String("config-precedence", defaultConfigPrecedence,
+		"Comma separated config sources from the highest to the lowest priority")
+	f.Bool("check-config", false, "Validate the config from all sources, then exit without starting the server")
 	f.Bool("in-cluster", false, "Set when running from a k8s cluster")
+	f.Bool("disable-in-cluster-detection", false,
+		"Never enable in-cluster mode automatically, even when service account files are present")
 	f.Bool("dev", false, "Allow connections from other origins")
+	f.Bool("disable-dev-endpoints", false, "Force off all debug/dev surfaces (e.g. --dev), regardless of their flags")
 	f.Bool("insecure-ssl", false, "Accept/Ignore all server SSL certificates")
 	f.Bool("enable-dynamic-clusters", false, "Enable dynamic clusters, which stores stateless clusters in the frontend.")
 	// Note: When running in-cluster and if not explicitly set, this flag defaults to false.
 	f.Bool("watch-plugins-changes", true, "Reloads plugins when there are changes to them or their directory")
 
 	f.String("kubeconfig", "", "Absolute path to the kubeconfig file")
-	f.String("skipped-kube-contexts", "", "Context name which should be ignored in kubeconfig file")
+	f.String("kubeconfig-base64", "", "Base64 encoded kubeconfig content, written to a temporary file on startup")
+	f.String("skipped-kube-contexts", "", "A comma separated list of context names ignored in the kubeconfig files")
+	f.String("default-context-fallback", DefaultContextFallbackNone,
+		"Context active by default when the kubeconfig has no current-context: first, none, or a context name")
 	f.String("html-static-dir", "", "Static HTML directory to serve")
+	f.String("static-cache-control", defaultStaticCacheControl,
+		"Cache-Control header for static assets; index.html is always served with no-cache")
+	f.String("temp-dir", os.TempDir(), "Directory for temporary files generated by Headlamp")
 	f.String("plugins-dir", defaultPluginDir(), "Specify the plugins directory to build the backend with")
 	f.String("base-url", "", "Base URL path. eg. /headlamp")
 	f.String("listen-addr", "", "Address to listen on; default is empty, which means listening to any address")
 	f.Uint("port", defaultPort, "Port to listen from")
+	durationFlag(f, "startup-timeout", 0, "Maximum time allowed for initialization before exiting; 0 means no bound")
+	durationFlag(f, "watch-drain-timeout", 0,
+		"How long open requests, like watch streams, may continue on shutdown before being closed")
+	durationFlag(f, "global-request-timeout", 0,
+		"Maximum time to handle a request, including the call to the cluster; watches and streams are exempt")
+	f.Float64("per-user-rate-limit", 0,
+		"Requests per second allowed for each OIDC user, else client IP; 0 disables rate limiting")
+	f.Int("per-user-rate-burst", 0,
+		"Requests a user can make at once above per-user-rate-limit; 0 uses the rate limit rounded up")
+	f.String("per-user-rate-limit-trusted-proxies", "",
+		"A comma separated list of IPs or CIDR ranges of proxies whose X-Forwarded-For gives the client IP to rate limit")
+	f.Bool("access-log", false, "Log every HTTP request")
+	f.String("access-log-format", AccessLogFormatCombined, "Format of the access log: combined or json")
+	f.String("log-redact-params", defaultLogRedactParams,
+		"A comma separated list of query parameters whose values are redacted in access logs")
 	f.String("proxy-urls", "", "Allow proxy requests to specified URLs")
+	f.String("proxy-urls-file", "",
+		"File with additional allowed proxy URLs, one per line, re-read every proxy-urls-refresh")
+	durationFlag(f, "proxy-urls-refresh", time.Minute, "How often to re-read proxy-urls-file; 0 reads it only once")
+	f.String("proxy-strip-response-headers", defaultProxyStripResponseHeaders,
+		"A comma separated list of headers removed from the responses of the cluster proxy")
+	f.String("proxy-add-request-headers", "",
+		"A comma separated list of name=value headers set on the requests of the cluster and external proxies")
+	f.Bool("block-private-proxy-targets", false,
+		"Refuse external proxy requests to private, loopback and link-local addresses")
+	f.String("allow-private-proxy-targets", "",
+		"A comma separated list of IPs or CIDR ranges allowed despite block-private-proxy-targets")
+	f.Int("list-chunk-size", defaultListChunkSize,
+		"Number of items to request per page when listing resources; 0 disables chunking")
+	f.Int("api-max-idle-conns", 0,
+		"Maximum idle connections kept to the Kubernetes API servers; 0 keeps the client-go default of no limit")
+	f.Int("api-max-idle-conns-per-host", 0,
+		"Maximum idle connections kept to each Kubernetes API server; 0 keeps the client-go default of 25")
 
 	f.String("oidc-client-id", "", "ClientID for OIDC")
 	f.String("oidc-client-secret", "", "ClientSecret for OIDC")
-	f.String("oidc-validator-client-id", "", "Override ClientID for OIDC during validation")
+	f.String("oidc-validator-client-id", "",
+		"Override ClientID for OIDC during validation; a comma separated list accepts tokens for any of them")
 	f.String("oidc-idp-issuer-url", "", "Identity provider issuer URL for OIDC")
 	f.String("oidc-validator-idp-issuer-url", "", "Override Identity provider issuer URL for OIDC during validation")
+	f.String("oidc-issuer-alias", "",
+		"A comma separated list of alternate issuer values accepted in the iss claim of OIDC tokens")
 	f.String("oidc-scopes", "profile,email",
 		"A comma separated list of scopes needed from the OIDC provider")
 	f.Bool("oidc-use-access-token", false, "Setup oidc to pass through the access_token instead of the default id_token")
+	f.Bool("oidc-userinfo-enabled", false, "Fetch user claims from the OIDC UserInfo endpoint after login")
+	f.Bool("oidc-groups-from-userinfo", false,
+		"Source groups from the OIDC UserInfo endpoint instead of the token; requires oidc-userinfo-enabled")
+	durationFlag(f, "oidc-session-ttl", 0,
+		"Maximum lifetime of an OIDC login session, regardless of token expiry; 0 follows the token")
+	f.String("oidc-allowed-redirect-hosts", "",
+		"A comma separated list of hosts the OIDC login may redirect to; any host of the request if not set")
+	f.String("oidc-tls-server-name", "",
+		"Server name to verify the TLS certificate of the OIDC provider against, e.g. when reached through a proxy")
+	durationFlag(f, "oidc-discovery-cache-ttl", 0,
+		"How long the OIDC discovery document and signing keys are cached; 0 fetches them each time")
+	f.String("oidc-max-token-size", defaultOidcMaxTokenSize.String(),
+		"Largest OIDC token accepted at login, e.g. 16KiB; larger tokens are rejected")
+	f.String("oidc-cookie-encryption-key", "",
+		"Key encrypting the OIDC login cookies: 32 bytes, hex or base64 encoded; generated on startup if not set")
+	f.String("oidc-cookie-encryption-key-file", "", "File holding the oidc-cookie-encryption-key")
+	f.String("session-store", SessionStoreMemory,
+		"Where to keep login sessions: memory, or file to keep them across restarts")
+	f.String("session-store-dir", "", "Directory of the session file when session-store is file")
 	// Telemetry flags.
 	f.String("service-name", "headlamp", "Service name for telemetry")
 	f.String("service-version", "0.30.0", "Service version for telemetry")
 	f.Bool("tracing-enabled", false, "Enable distributed tracing")
 	f.Bool("metrics-enabled", false, "Enable metrics collection")
-	f.String("otlp-endpoint", "localhost:4317", "OTLP collector endpoint")
+	f.String("otlp-endpoint", "localhost:4317", "OTLP collector endpoint, exported to with TLS if it starts with https://")
 	f.Bool("use-otlp-http", false, "Use HTTP instead of gRPC for OTLP export")
+	f.Bool("otlp-insecure", false, "Disable TLS for OTLP export to an https:// otlp-endpoint")
 	f.Bool("stdout-trace-enabled", false, "Enable tracing output to stdout")
 	f.Float64("sampling-rate", 1.0, "Sampling rate for traces")
+	f.String("metrics-exclude-paths", "",
+		"A comma separated list of paths to skip in request metrics; a trailing '*' matches by prefix, e.g. /clusters/*")
+	f.Bool("disable-build-info-metric", false, "Do not export the headlamp_build_info metric")
+	f.Bool("telemetry-exclude-cluster-label", false,
+		"Do not add the k8s.cluster.context label with the target cluster to request traces and metrics")
+	f.String("metrics-latency-buckets", "",
+		"A comma separated list of ascending bucket boundaries, in milliseconds, for the request latency histogram")
 
 	return f
 }
@@ -307,14 +1776,24 @@ func defaultPluginDir() string {
 	return pluginsConfigDir
 }
 
+// GetDefaultKubeConfigPath returns ~/.kube/config for the current user. It
+// exits if the current user cannot be found.
 func GetDefaultKubeConfigPath() string {
-	user, err := user.Current()
+	path, err := defaultKubeConfigPath()
 	if err != nil {
 		logger.Log(logger.LevelError, nil, err, "getting current user")
 		os.Exit(1)
 	}
 
-	homeDirectory := user.HomeDir
+	return path
+}
+
+// defaultKubeConfigPath returns ~/.kube/config for the current user.
+func defaultKubeConfigPath() (string, error) {
+	user, err := user.Current()
+	if err != nil {
+		return "", fmt.Errorf("getting current user: %w", err)
+	}
 
-	return filepath.Join(homeDirectory, ".kube", "config")
+	return filepath.Join(user.HomeDir, ".kube", "config"), nil
 }
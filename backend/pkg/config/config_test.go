@@ -1,8 +1,16 @@
 package config_test
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"net/netip"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/kubernetes-sigs/headlamp/backend/pkg/config"
 	"github.com/stretchr/testify/assert"
@@ -20,6 +28,9 @@ func TestParse(t *testing.T) {
 		assert.Equal(t, "", conf.ListenAddr)
 		assert.Equal(t, uint(4466), conf.Port)
 		assert.Equal(t, "profile,email", conf.OidcScopes)
+		assert.Equal(t, 500, conf.ListChunkSize)
+		assert.Equal(t, os.TempDir(), conf.TempDir)
+		assert.Equal(t, "public, max-age=3600", conf.StaticCacheControl)
 	})
 
 	t.Run("with_args", func(t *testing.T) {
@@ -114,4 +125,1511 @@ func TestParse(t *testing.T) {
 
 		assert.Equal(t, true, conf.EnableDynamicClusters)
 	})
+	t.Run("disable_dev_endpoints", func(t *testing.T) {
+		args := []string{
+			"go run ./cmd", "--dev", "--disable-dev-endpoints",
+		}
+		conf, err := config.Parse(args)
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.Equal(t, true, conf.DisableDevEndpoints)
+		assert.Equal(t, false, conf.DevMode)
+	})
+	t.Run("oidc_issuer_alias", func(t *testing.T) {
+		args := []string{
+			"go run ./cmd", "-in-cluster",
+			"--oidc-issuer-alias=https://idp.internal/realms/a, https://idp.example.com/realms/a,",
+		}
+		conf, err := config.Parse(args)
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.Equal(t, []string{"https://idp.internal/realms/a", "https://idp.example.com/realms/a"},
+			conf.OidcIssuerAliasList())
+	})
+
+	t.Run("invalid_oidc_issuer_alias", func(t *testing.T) {
+		args := []string{
+			"go run ./cmd", "-in-cluster", "--oidc-issuer-alias=idp.internal/realms/a",
+		}
+		conf, err := config.Parse(args)
+
+		require.Error(t, err)
+		require.Nil(t, conf)
+
+		assert.Contains(t, err.Error(), "oidc-issuer-alias")
+	})
+	t.Run("telemetry_exclude_cluster_label", func(t *testing.T) {
+		conf, err := config.Parse([]string{"go run ./cmd", "--metrics-enabled", "--telemetry-exclude-cluster-label"})
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.True(t, conf.TelemetryExcludeClusterLabel)
+	})
+
+	t.Run("check_config", func(t *testing.T) {
+		conf, err := config.Parse([]string{"go run ./cmd", "--check-config"})
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.True(t, conf.CheckConfig)
+	})
+	t.Run("invalid_list_chunk_size", func(t *testing.T) {
+		args := []string{
+			"go run ./cmd", "--list-chunk-size=-1",
+		}
+		conf, err := config.Parse(args)
+
+		require.Error(t, err)
+		require.Nil(t, conf)
+
+		assert.Contains(t, err.Error(), "list-chunk-size")
+	})
+	t.Run("metrics_exclude_paths", func(t *testing.T) {
+		args := []string{
+			"go run ./cmd", "--metrics-exclude-paths=/clusters/*, /wsMultiplexer",
+		}
+		conf, err := config.Parse(args)
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.Equal(t, []string{"/clusters/*", "/wsMultiplexer"}, conf.MetricsExcludePathList())
+	})
+
+	t.Run("invalid_metrics_exclude_paths", func(t *testing.T) {
+		args := []string{
+			"go run ./cmd", "--metrics-exclude-paths=clusters/*",
+		}
+		conf, err := config.Parse(args)
+
+		require.Error(t, err)
+		require.Nil(t, conf)
+
+		assert.Contains(t, err.Error(), "metrics-exclude-paths")
+	})
+	t.Run("kubeconfig_base64", func(t *testing.T) {
+		kubeConfig := `apiVersion: v1
+kind: Config
+clusters:
+- name: minikube
+  cluster:
+    server: https://127.0.0.1:8443
+contexts:
+- name: minikube
+  context:
+    cluster: minikube
+current-context: minikube
+`
+		os.Setenv("HEADLAMP_CONFIG_KUBECONFIG_BASE64", base64.StdEncoding.EncodeToString([]byte(kubeConfig)))
+		defer os.Unsetenv("HEADLAMP_CONFIG_KUBECONFIG_BASE64")
+
+		conf, err := config.Parse([]string{"go run ./cmd"})
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		data, err := os.ReadFile(conf.KubeConfigPath)
+		require.NoError(t, err)
+		assert.Equal(t, kubeConfig, string(data))
+
+		require.NoError(t, conf.Cleanup())
+
+		_, err = os.Stat(conf.KubeConfigPath)
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("invalid_kubeconfig_base64", func(t *testing.T) {
+		args := []string{
+			"go run ./cmd", "--kubeconfig-base64=not-base64!",
+		}
+		conf, err := config.Parse(args)
+
+		require.Error(t, err)
+		require.Nil(t, conf)
+
+		assert.Contains(t, err.Error(), "kubeconfig-base64")
+	})
+
+	t.Run("kubeconfig_base64_not_a_kubeconfig", func(t *testing.T) {
+		args := []string{
+			"go run ./cmd", "--kubeconfig-base64=" + base64.StdEncoding.EncodeToString([]byte("foo: bar")),
+		}
+		conf, err := config.Parse(args)
+
+		require.Error(t, err)
+		require.Nil(t, conf)
+
+		assert.Contains(t, err.Error(), "not a valid kubeconfig")
+	})
+	t.Run("oidc_groups_from_userinfo_requires_userinfo", func(t *testing.T) {
+		conf, err := config.Parse([]string{"go run ./cmd", "-in-cluster", "--oidc-groups-from-userinfo"})
+
+		require.Error(t, err)
+		require.Nil(t, conf)
+
+		assert.Contains(t, err.Error(), "requires oidc-userinfo-enabled")
+	})
+	t.Run("oidc_groups_from_userinfo", func(t *testing.T) {
+		conf, err := config.Parse([]string{
+			"go run ./cmd", "-in-cluster", "--oidc-userinfo-enabled", "--oidc-groups-from-userinfo",
+		})
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.Equal(t, true, conf.OidcGroupsFromUserInfo)
+	})
+	t.Run("otlp_insecure", func(t *testing.T) {
+		args := []string{
+			"go run ./cmd", "--tracing-enabled", "--use-otlp-http", "--otlp-insecure",
+		}
+		conf, err := config.Parse(args)
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.Equal(t, true, *conf.OTLPInsecure)
+	})
+
+	t.Run("otlp_insecure_without_endpoint", func(t *testing.T) {
+		args := []string{
+			"go run ./cmd", "--tracing-enabled", "--otlp-insecure", "--otlp-endpoint=",
+		}
+		conf, err := config.Parse(args)
+
+		require.Error(t, err)
+		require.Nil(t, conf)
+
+		assert.Contains(t, err.Error(), "otlp-insecure")
+	})
+	t.Run("config_file", func(t *testing.T) {
+		configFile := writeConfigFile(t, "port: 5555\nbase-url: /headlamp\nenable-helm: true\n")
+
+		conf, err := config.Parse([]string{"go run ./cmd", "--config-file=" + configFile})
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.Equal(t, uint(5555), conf.Port)
+		assert.Equal(t, "/headlamp", conf.BaseURL)
+		assert.Equal(t, true, conf.EnableHelm)
+	})
+
+	t.Run("default_config_precedence", func(t *testing.T) {
+		configFile := writeConfigFile(t, "port: 5555\nbase-url: /from-file\nlisten-addr: 127.0.0.1\n")
+
+		os.Setenv("HEADLAMP_CONFIG_PORT", "1234")
+		os.Setenv("HEADLAMP_CONFIG_BASE_URL", "/from-env")
+		defer os.Unsetenv("HEADLAMP_CONFIG_PORT")
+		defer os.Unsetenv("HEADLAMP_CONFIG_BASE_URL")
+
+		conf, err := config.Parse([]string{"go run ./cmd", "--config-file=" + configFile, "--port=9876"})
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.Equal(t, uint(9876), conf.Port)
+		assert.Equal(t, "/from-env", conf.BaseURL)
+		assert.Equal(t, "127.0.0.1", conf.ListenAddr)
+	})
+
+	t.Run("reversed_config_precedence", func(t *testing.T) {
+		configFile := writeConfigFile(t, "port: 5555\n")
+
+		os.Setenv("HEADLAMP_CONFIG_PORT", "1234")
+		defer os.Unsetenv("HEADLAMP_CONFIG_PORT")
+
+		conf, err := config.Parse([]string{
+			"go run ./cmd", "--config-file=" + configFile, "--port=9876", "--config-precedence=file,env,flag",
+		})
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.Equal(t, uint(5555), conf.Port)
+	})
+
+	t.Run("invalid_config_precedence", func(t *testing.T) {
+		for _, precedence := range []string{"flag,env", "flag,env,env", "flag,env,file,consul"} {
+			conf, err := config.Parse([]string{"go run ./cmd", "--config-precedence=" + precedence})
+
+			require.Error(t, err, precedence)
+			require.Nil(t, conf)
+
+			assert.Contains(t, err.Error(), "config-precedence")
+		}
+	})
+
+	t.Run("missing_config_file", func(t *testing.T) {
+		conf, err := config.Parse([]string{"go run ./cmd", "--config-file=" + filepath.Join(t.TempDir(), "nope.yaml")})
+
+		require.Error(t, err)
+		require.Nil(t, conf)
+	})
+	t.Run("config_file_unknown_keys", func(t *testing.T) {
+		configFile := writeConfigFile(t, "port: 5555\nenabel-helm: true\noidc:\n  client-id: foo\n")
+
+		conf, err := config.Parse([]string{"go run ./cmd", "--config-file=" + configFile})
+
+		require.Error(t, err)
+		require.Nil(t, conf)
+
+		assert.Contains(t, err.Error(), "enabel-helm")
+		assert.Contains(t, err.Error(), "oidc.client-id")
+		assert.NotContains(t, err.Error(), "port")
+	})
+	t.Run("config_file_field_without_flag", func(t *testing.T) {
+		configFile := writeConfigFile(t, "jaeger-endpoint: localhost:14268\n")
+
+		conf, err := config.Parse([]string{"go run ./cmd", "--config-file=" + configFile})
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		require.NotNil(t, conf.JaegerEndpoint)
+		assert.Equal(t, "localhost:14268", *conf.JaegerEndpoint)
+	})
+	t.Run("config_file_accepts_every_field", func(t *testing.T) {
+		var content strings.Builder
+
+		configType := reflect.TypeFor[config.Config]()
+		for i := range configType.NumField() {
+			if key := configType.Field(i).Tag.Get("koanf"); key != "" {
+				content.WriteString(key + ": null\n")
+			}
+		}
+
+		configFile := writeConfigFile(t, content.String())
+
+		// The null values may not be valid, but no key must be unknown.
+		_, err := config.Parse([]string{"go run ./cmd", "--config-file=" + configFile})
+		if err != nil {
+			assert.NotContains(t, err.Error(), "unknown keys")
+		}
+	})
+	t.Run("startup_timeout", func(t *testing.T) {
+		os.Setenv("HEADLAMP_CONFIG_STARTUP_TIMEOUT", "30s")
+		defer os.Unsetenv("HEADLAMP_CONFIG_STARTUP_TIMEOUT")
+
+		conf, err := config.Parse([]string{"go run ./cmd"})
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.Equal(t, 30*time.Second, conf.StartupTimeout)
+	})
+
+	t.Run("invalid_startup_timeout", func(t *testing.T) {
+		conf, err := config.Parse([]string{"go run ./cmd", "--startup-timeout=-1s"})
+
+		require.Error(t, err)
+		require.Nil(t, conf)
+
+		assert.Contains(t, err.Error(), "startup-timeout")
+	})
+
+	t.Run("watch_drain_timeout", func(t *testing.T) {
+		conf, err := config.Parse([]string{"go run ./cmd", "--watch-drain-timeout=30s"})
+		require.NoError(t, err)
+		assert.Equal(t, 30*time.Second, conf.WatchDrainTimeout)
+
+		conf, err = config.Parse([]string{"go run ./cmd", "--watch-drain-timeout=-1s"})
+		require.Error(t, err)
+		require.Nil(t, conf)
+		assert.Contains(t, err.Error(), "watch-drain-timeout")
+	})
+	t.Run("static_cache_control", func(t *testing.T) {
+		for _, value := range []string{"no-store", "public, max-age=86400, immutable", `private, no-cache="Set-Cookie"`} {
+			conf, err := config.Parse([]string{"go run ./cmd", "--static-cache-control=" + value})
+
+			require.NoError(t, err, value)
+			require.NotNil(t, conf)
+
+			assert.Equal(t, value, conf.StaticCacheControl)
+		}
+	})
+
+	t.Run("invalid_static_cache_control", func(t *testing.T) {
+		for _, value := range []string{"max-age=1\r\nX-Injected: 1", "public,,max-age=1", "max age=1"} {
+			conf, err := config.Parse([]string{"go run ./cmd", "--static-cache-control=" + value})
+
+			require.Error(t, err, value)
+			require.Nil(t, conf)
+
+			assert.Contains(t, err.Error(), "static-cache-control")
+		}
+	})
+	t.Run("proxy_urls_file", func(t *testing.T) {
+		proxyURLsFile := filepath.Join(t.TempDir(), "proxy-urls")
+		require.NoError(t, os.WriteFile(proxyURLsFile,
+			[]byte("# allowed upstreams\nhttps://artifacthub.io/*\n\nhttps://*.example.com/*, https://example.org:8443/*\n"),
+			0o600))
+
+		conf, err := config.Parse([]string{"go run ./cmd", "--proxy-urls-file=" + proxyURLsFile})
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		proxyURLs, err := config.LoadProxyURLsFile(conf.ProxyURLsFile)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"https://artifacthub.io/*", "https://*.example.com/*", "https://example.org:8443/*"},
+			proxyURLs)
+		assert.Equal(t, time.Minute, conf.ProxyURLsRefresh)
+	})
+
+	t.Run("invalid_proxy_urls_file", func(t *testing.T) {
+		proxyURLsFile := filepath.Join(t.TempDir(), "proxy-urls")
+		require.NoError(t, os.WriteFile(proxyURLsFile, []byte("https://artifacthub.io/*\nartifacthub.io\n"), 0o600))
+
+		conf, err := config.Parse([]string{"go run ./cmd", "--proxy-urls-file=" + proxyURLsFile})
+
+		require.Error(t, err)
+		require.Nil(t, conf)
+
+		assert.Contains(t, err.Error(), "artifacthub.io")
+	})
+
+	t.Run("missing_proxy_urls_file", func(t *testing.T) {
+		conf, err := config.Parse([]string{
+			"go run ./cmd", "--proxy-urls-file=" + filepath.Join(t.TempDir(), "nope"),
+		})
+
+		require.Error(t, err)
+		require.Nil(t, conf)
+
+		assert.Contains(t, err.Error(), "proxy-urls-file")
+	})
+	t.Run("temp_dir", func(t *testing.T) {
+		tempDir := t.TempDir()
+		kubeConfig := "kind: Config\ncontexts:\n- name: minikube\n  context:\n    cluster: minikube\n"
+
+		conf, err := config.Parse([]string{
+			"go run ./cmd", "--temp-dir=" + tempDir,
+			"--kubeconfig-base64=" + base64.StdEncoding.EncodeToString([]byte(kubeConfig)),
+		})
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		defer conf.Cleanup()
+
+		assert.Equal(t, tempDir, filepath.Dir(conf.KubeConfigPath))
+	})
+
+	t.Run("invalid_temp_dir", func(t *testing.T) {
+		tempDir := filepath.Join(t.TempDir(), "nope")
+
+		conf, err := config.Parse([]string{"go run ./cmd", "--temp-dir=" + tempDir})
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		err = conf.CheckTempDir()
+		require.Error(t, err)
+
+		assert.Contains(t, err.Error(), "temp-dir")
+		assert.NoDirExists(t, tempDir)
+	})
+	t.Run("multiple_validator_client_ids", func(t *testing.T) {
+		conf, err := config.Parse([]string{
+			"go run ./cmd", "-in-cluster", "--oidc-validator-client-id=tenant-a, tenant-b",
+		})
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.Equal(t, []string{"tenant-a", "tenant-b"}, conf.ValidatorClientIDs())
+	})
+
+	t.Run("empty_validator_client_id_entry", func(t *testing.T) {
+		conf, err := config.Parse([]string{
+			"go run ./cmd", "-in-cluster", "--oidc-validator-client-id=tenant-a,,tenant-b",
+		})
+
+		require.Error(t, err)
+		require.Nil(t, conf)
+
+		assert.Contains(t, err.Error(), "oidc-validator-client-id")
+	})
+
+	t.Run("multiple_validator_client_ids_without_incluster", func(t *testing.T) {
+		conf, err := config.Parse([]string{"go run ./cmd", "--oidc-validator-client-id=tenant-a,tenant-b"})
+
+		require.Error(t, err)
+		require.Nil(t, conf)
+
+		assert.Contains(t, err.Error(), "are only meant to be used in inCluster mode")
+	})
+	t.Run("disable_in_cluster_detection", func(t *testing.T) {
+		conf, err := config.Parse([]string{"go run ./cmd", "--disable-in-cluster-detection"})
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.Equal(t, true, conf.DisableInClusterDetection)
+		assert.Equal(t, false, conf.InCluster)
+	})
+	t.Run("per_user_rate_limit", func(t *testing.T) {
+		conf, err := config.Parse([]string{"go run ./cmd", "--per-user-rate-limit=2.5"})
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.Equal(t, 2.5, conf.PerUserRateLimit)
+		assert.Equal(t, 0, conf.PerUserRateBurst)
+		assert.Equal(t, 3, conf.EffectivePerUserRateBurst())
+	})
+
+	t.Run("per_user_rate_burst", func(t *testing.T) {
+		conf, err := config.Parse([]string{"go run ./cmd", "--per-user-rate-limit=5", "--per-user-rate-burst=20"})
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.Equal(t, 20, conf.EffectivePerUserRateBurst())
+	})
+	t.Run("per_user_rate_limit_trusted_proxies", func(t *testing.T) {
+		conf, err := config.Parse([]string{
+			"go run ./cmd", "--per-user-rate-limit=5", "--per-user-rate-limit-trusted-proxies=10.0.0.0/8, 192.168.1.10",
+		})
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.168.1.10/32")},
+			conf.PerUserRateTrustedProxyList())
+	})
+	t.Run("invalid_per_user_rate_limit", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"go run ./cmd", "--per-user-rate-limit=-1"},
+			{"go run ./cmd", "--per-user-rate-limit=1", "--per-user-rate-burst=-1"},
+			{"go run ./cmd", "--per-user-rate-burst=10"},
+			{"go run ./cmd", "--per-user-rate-limit-trusted-proxies=10.0.0.0/8"},
+			{"go run ./cmd", "--per-user-rate-limit=1", "--per-user-rate-limit-trusted-proxies=ingress"},
+		} {
+			conf, err := config.Parse(args)
+
+			require.Error(t, err)
+			require.Nil(t, conf)
+
+			assert.Contains(t, err.Error(), "per-user-rate")
+		}
+	})
+	t.Run("access_log", func(t *testing.T) {
+		conf, err := config.Parse(nil)
+		require.NoError(t, err)
+		assert.False(t, conf.AccessLog)
+		assert.Equal(t, config.AccessLogFormatCombined, conf.AccessLogFormat)
+
+		conf, err = config.Parse([]string{"go run ./cmd", "--access-log", "--access-log-format=json"})
+		require.NoError(t, err)
+		assert.True(t, conf.AccessLog)
+		assert.Equal(t, config.AccessLogFormatJSON, conf.AccessLogFormat)
+	})
+
+	t.Run("invalid_access_log_format", func(t *testing.T) {
+		conf, err := config.Parse([]string{"go run ./cmd", "--access-log", "--access-log-format=apache"})
+
+		require.Error(t, err)
+		require.Nil(t, conf)
+
+		assert.Contains(t, err.Error(), "access-log-format")
+	})
+
+	t.Run("log_redact_params", func(t *testing.T) {
+		conf, err := config.Parse(nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"code", "state", "token"}, conf.LogRedactParamList())
+
+		conf, err = config.Parse([]string{"go run ./cmd", "--log-redact-params=code, state,token,session"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"code", "state", "token", "session"}, conf.LogRedactParamList())
+	})
+
+	t.Run("invalid_log_redact_params", func(t *testing.T) {
+		conf, err := config.Parse([]string{"go run ./cmd", "--log-redact-params=code,,state"})
+
+		require.Error(t, err)
+		require.Nil(t, conf)
+
+		assert.Contains(t, err.Error(), "log-redact-params")
+	})
+
+	t.Run("oidc_session_ttl", func(t *testing.T) {
+		args := []string{
+			"go run ./cmd", "--in-cluster", "--oidc-client-id=headlamp",
+			"--oidc-idp-issuer-url=https://issuer.example.com", "--oidc-session-ttl=8h",
+		}
+		conf, err := config.Parse(args)
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.Equal(t, 8*time.Hour, conf.OidcSessionTTL)
+	})
+
+	t.Run("invalid_oidc_session_ttl", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"go run ./cmd", "--oidc-session-ttl=-1h"},
+			{"go run ./cmd", "--in-cluster", "--oidc-session-ttl=8h"},
+		} {
+			conf, err := config.Parse(args)
+
+			require.Error(t, err)
+			require.Nil(t, conf)
+
+			assert.Contains(t, err.Error(), "oidc-session-ttl")
+		}
+	})
+	t.Run("session_store", func(t *testing.T) {
+		conf, err := config.Parse(nil)
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.Equal(t, config.SessionStoreMemory, conf.SessionStore)
+
+		dir := t.TempDir()
+		args := []string{
+			"go run ./cmd", "--session-store=file", "--session-store-dir=" + dir,
+		}
+		conf, err = config.Parse(args)
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.Equal(t, config.SessionStoreFile, conf.SessionStore)
+		assert.Equal(t, dir, conf.SessionStoreDir)
+	})
+
+	t.Run("session_store_dir_not_created", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "sessions", "headlamp")
+		args := []string{
+			"go run ./cmd", "--session-store=file", "--session-store-dir=" + dir,
+		}
+		conf, err := config.Parse(args)
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		// The directory is created when the store is opened, not by Parse.
+		assert.NoDirExists(t, filepath.Dir(dir))
+	})
+
+	t.Run("invalid_session_store", func(t *testing.T) {
+		notADir := filepath.Join(t.TempDir(), "file")
+		require.NoError(t, os.WriteFile(notADir, nil, 0o600))
+
+		tests := []struct {
+			args     []string
+			errorMsg string
+		}{
+			{
+				args:     []string{"go run ./cmd", "--session-store=redis"},
+				errorMsg: `session-store: unknown store "redis"`,
+			},
+			{
+				args:     []string{"go run ./cmd", "--session-store=file"},
+				errorMsg: "session-store-dir is required when session-store is file",
+			},
+			{
+				args:     []string{"go run ./cmd", "--session-store=file", "--session-store-dir=" + notADir},
+				errorMsg: "not a directory",
+			},
+			{
+				args:     []string{"go run ./cmd", "--session-store=file", "--session-store-dir=" + notADir + "/sessions"},
+				errorMsg: "not a directory",
+			},
+		}
+
+		for _, tt := range tests {
+			conf, err := config.Parse(tt.args)
+
+			require.Error(t, err)
+			require.Nil(t, conf)
+
+			assert.Contains(t, err.Error(), tt.errorMsg)
+		}
+	})
+	t.Run("default_context_fallback", func(t *testing.T) {
+		conf, err := config.Parse(nil)
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.Equal(t, config.DefaultContextFallbackNone, conf.DefaultContextFallback)
+
+		conf, err = config.Parse([]string{"go run ./cmd", "--default-context-fallback=first"})
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.Equal(t, config.DefaultContextFallbackFirst, conf.DefaultContextFallback)
+	})
+
+	t.Run("invalid_default_context_fallback", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"go run ./cmd", "--default-context-fallback="},
+			{"go run ./cmd", "--default-context-fallback= kind"},
+			{"go run ./cmd", "--default-context-fallback=kind", "--skipped-kube-contexts=kind"},
+		} {
+			conf, err := config.Parse(args)
+
+			require.Error(t, err)
+			require.Nil(t, conf)
+
+			assert.Contains(t, err.Error(), "default-context-fallback")
+		}
+	})
+	t.Run("disable_build_info_metric", func(t *testing.T) {
+		args := []string{
+			"go run ./cmd", "--metrics-enabled", "--disable-build-info-metric",
+		}
+		conf, err := config.Parse(args)
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.Equal(t, true, conf.DisableBuildInfoMetric)
+	})
+	t.Run("metrics_latency_buckets", func(t *testing.T) {
+		conf, err := config.Parse(nil)
+		require.NoError(t, err)
+		assert.Nil(t, conf.MetricsLatencyBucketList())
+
+		conf, err = config.Parse([]string{
+			"go run ./cmd", "--metrics-enabled", "--metrics-latency-buckets=0.5, 10,250,5000",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []float64{0.5, 10, 250, 5000}, conf.MetricsLatencyBucketList())
+	})
+
+	t.Run("invalid_metrics_latency_buckets", func(t *testing.T) {
+		for _, value := range []string{"10,fast", "0,10", "-5,10", "10,100,50", "10,10"} {
+			conf, err := config.Parse([]string{"go run ./cmd", "--metrics-latency-buckets=" + value})
+
+			require.Error(t, err)
+			require.Nil(t, conf)
+
+			assert.Contains(t, err.Error(), "metrics-latency-buckets")
+		}
+	})
+
+	t.Run("oidc_cookie_encryption_key", func(t *testing.T) {
+		key := bytes.Repeat([]byte{7}, 32)
+
+		for _, encoded := range []string{hex.EncodeToString(key), base64.StdEncoding.EncodeToString(key)} {
+			conf, err := config.Parse([]string{"go run ./cmd", "--oidc-cookie-encryption-key=" + encoded})
+
+			require.NoError(t, err)
+			require.NotNil(t, conf)
+
+			decoded, err := conf.CookieEncryptionKeyBytes()
+			require.NoError(t, err)
+			assert.Equal(t, key, decoded)
+		}
+	})
+
+	t.Run("oidc_cookie_encryption_key_file", func(t *testing.T) {
+		key := bytes.Repeat([]byte{7}, 32)
+		keyFile := filepath.Join(t.TempDir(), "key")
+		require.NoError(t, os.WriteFile(keyFile, []byte(hex.EncodeToString(key)+"\n"), 0o600))
+
+		conf, err := config.Parse([]string{"go run ./cmd", "--oidc-cookie-encryption-key-file=" + keyFile})
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		decoded, err := conf.CookieEncryptionKeyBytes()
+		require.NoError(t, err)
+		assert.Equal(t, key, decoded)
+	})
+
+	t.Run("oidc_cookie_encryption_key_generated", func(t *testing.T) {
+		args := []string{
+			"go run ./cmd", "--in-cluster", "--oidc-client-id=headlamp",
+			"--oidc-idp-issuer-url=https://issuer.example.com",
+		}
+		conf, err := config.Parse(args)
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		_, err = conf.CookieEncryptionKeyBytes()
+		assert.NoError(t, err)
+
+		conf, err = config.Parse(nil)
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.Empty(t, conf.CookieEncryptionKey)
+	})
+
+	t.Run("invalid_oidc_cookie_encryption_key", func(t *testing.T) {
+		conf, err := config.Parse([]string{"go run ./cmd", "--oidc-cookie-encryption-key=not-a-key"})
+
+		require.Error(t, err)
+		require.Nil(t, conf)
+
+		assert.Contains(t, err.Error(), "oidc-cookie-encryption-key")
+	})
+	t.Run("proxy_strip_response_headers", func(t *testing.T) {
+		conf, err := config.Parse(nil)
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.Contains(t, conf.ProxyStripResponseHeaderList(), "Transfer-Encoding")
+
+		conf, err = config.Parse([]string{"go run ./cmd", "--proxy-strip-response-headers=x-internal-token, Server"})
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.Equal(t, []string{"X-Internal-Token", "Server"}, conf.ProxyStripResponseHeaderList())
+	})
+
+	t.Run("invalid_proxy_strip_response_headers", func(t *testing.T) {
+		conf, err := config.Parse([]string{"go run ./cmd", "--proxy-strip-response-headers=Server,Bad Header"})
+
+		require.Error(t, err)
+		require.Nil(t, conf)
+
+		assert.Contains(t, err.Error(), "proxy-strip-response-headers")
+	})
+	t.Run("proxy_add_request_headers", func(t *testing.T) {
+		args := []string{
+			"go run ./cmd", "--proxy-add-request-headers=x-tenant=team-a, Impersonate-Group = admins,X-Query=a=b",
+		}
+		conf, err := config.Parse(args)
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.Equal(t, map[string]string{
+			"X-Tenant":          "team-a",
+			"Impersonate-Group": "admins",
+			"X-Query":           "a=b",
+		}, conf.ProxyAddRequestHeaderMap())
+	})
+
+	t.Run("invalid_proxy_add_request_headers", func(t *testing.T) {
+		for _, value := range []string{"X-Tenant", "Bad Header=value", "=value"} {
+			conf, err := config.Parse([]string{"go run ./cmd", "--proxy-add-request-headers=" + value})
+
+			require.Error(t, err)
+			require.Nil(t, conf)
+
+			assert.Contains(t, err.Error(), "proxy-add-request-headers")
+		}
+	})
+	t.Run("block_private_proxy_targets", func(t *testing.T) {
+		args := []string{
+			"go run ./cmd", "--block-private-proxy-targets", "--allow-private-proxy-targets=10.1.0.0/16, 192.168.1.5",
+		}
+		conf, err := config.Parse(args)
+
+		require.NoError(t, err)
+		require.NotNil(t, conf)
+
+		assert.Equal(t, []netip.Prefix{
+			netip.MustParsePrefix("10.1.0.0/16"),
+			netip.MustParsePrefix("192.168.1.5/32"),
+		}, conf.AllowPrivateProxyTargetList())
+	})
+
+	t.Run("invalid_private_proxy_targets", func(t *testing.T) {
+		for _, args := range [][]string{
+			{"go run ./cmd", "--allow-private-proxy-targets=10.0.0.0/8"},
+			{"go run ./cmd", "--block-private-proxy-targets", "--allow-private-proxy-targets=internal.example.com"},
+		} {
+			conf, err := config.Parse(args)
+
+			require.Error(t, err)
+			require.Nil(t, conf)
+
+			assert.Contains(t, err.Error(), "allow-private-proxy-targets")
+		}
+	})
+
+	t.Run("oidc_allowed_redirect_hosts", func(t *testing.T) {
+		conf, err := config.Parse([]string{
+			"go run ./cmd", "--in-cluster", "--oidc-client-id=headlamp",
+			"--oidc-idp-issuer-url=https://issuer.example.com",
+			"--oidc-allowed-redirect-hosts=headlamp.example.com, 10.0.0.1,localhost",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"headlamp.example.com", "10.0.0.1", "localhost"}, conf.OidcAllowedRedirectHostList())
+	})
+
+	t.Run("oidc_allowed_redirect_hosts_default", func(t *testing.T) {
+		conf, err := config.Parse([]string{"go run ./cmd", "--listen-addr=headlamp.example.com"})
+		require.NoError(t, err)
+		assert.Empty(t, conf.OidcAllowedRedirectHostList())
+	})
+
+	t.Run("oidc_allowed_redirect_hosts_kubeconfig_oidc", func(t *testing.T) {
+		// Users logging in with the OIDC settings of their kubeconfig do not
+		// set oidc-client-id.
+		conf, err := config.Parse([]string{"go run ./cmd", "--oidc-allowed-redirect-hosts=headlamp.example.com"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"headlamp.example.com"}, conf.OidcAllowedRedirectHostList())
+	})
+
+	t.Run("invalid_oidc_allowed_redirect_hosts", func(t *testing.T) {
+		oidcArgs := []string{
+			"go run ./cmd", "--in-cluster", "--oidc-client-id=headlamp",
+			"--oidc-idp-issuer-url=https://issuer.example.com",
+		}
+
+		for _, args := range [][]string{
+			append(oidcArgs, "--oidc-allowed-redirect-hosts=https://headlamp.example.com"),
+			append(oidcArgs, "--oidc-allowed-redirect-hosts=headlamp.example.com:443"),
+			append(oidcArgs, "--oidc-allowed-redirect-hosts=-headlamp.example.com"),
+		} {
+			conf, err := config.Parse(args)
+
+			require.Error(t, err)
+			require.Nil(t, conf)
+
+			assert.Contains(t, err.Error(), "oidc-allowed-redirect-hosts")
+		}
+	})
+
+	t.Run("oidc_tls_server_name", func(t *testing.T) {
+		conf, err := config.Parse([]string{
+			"go run ./cmd", "--in-cluster", "--oidc-client-id=headlamp",
+			"--oidc-idp-issuer-url=https://proxy.internal", "--oidc-tls-server-name=login.example.com",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "login.example.com", conf.OidcTLSServerName)
+	})
+
+	t.Run("invalid_oidc_tls_server_name", func(t *testing.T) {
+		for _, args := range [][]string{
+			{
+				"go run ./cmd", "--in-cluster", "--oidc-client-id=headlamp",
+				"--oidc-idp-issuer-url=https://proxy.internal", "--oidc-tls-server-name=https://login.example.com",
+			},
+			{"go run ./cmd", "--oidc-tls-server-name=login.example.com"},
+		} {
+			conf, err := config.Parse(args)
+
+			require.Error(t, err)
+			require.Nil(t, conf)
+
+			assert.Contains(t, err.Error(), "oidc-tls-server-name")
+		}
+	})
+
+	t.Run("oidc_max_token_size", func(t *testing.T) {
+		conf, err := config.Parse(nil)
+		require.NoError(t, err)
+		assert.Equal(t, config.ByteSize(64<<10), conf.OidcMaxTokenSize)
+
+		conf, err = config.Parse([]string{"go run ./cmd", "--oidc-max-token-size=16KB"})
+		require.NoError(t, err)
+		assert.Equal(t, config.ByteSize(16000), conf.OidcMaxTokenSize)
+
+		t.Setenv("HEADLAMP_CONFIG_OIDC_MAX_TOKEN_SIZE", "1MiB")
+
+		conf, err = config.Parse(nil)
+		require.NoError(t, err)
+		assert.Equal(t, config.ByteSize(1<<20), conf.OidcMaxTokenSize)
+	})
+
+	t.Run("invalid_oidc_max_token_size", func(t *testing.T) {
+		for _, value := range []string{"0", "lots", "16XB"} {
+			conf, err := config.Parse([]string{"go run ./cmd", "--oidc-max-token-size=" + value})
+
+			require.Error(t, err)
+			require.Nil(t, conf)
+		}
+	})
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+
+	configFile := filepath.Join(t.TempDir(), "headlamp.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(content), 0o600))
+
+	return configFile
+}
+
+func TestEffectiveOidcScopes(t *testing.T) {
+	tests := []struct {
+		name               string
+		scopes             string
+		clientID           string
+		userInfo           bool
+		groupsFromUserInfo bool
+		want               []string
+	}{
+		{name: "default", scopes: "profile,email", want: []string{"profile", "email"}},
+		{name: "whitespace", scopes: " profile , email ", want: []string{"profile", "email"}},
+		{name: "duplicates", scopes: "profile,email,profile,,email", want: []string{"profile", "email"}},
+		{name: "empty", scopes: "", want: []string{}},
+		{
+			name: "refresh", scopes: "profile,email", clientID: "headlamp",
+			want: []string{"profile", "email", "offline_access"},
+		},
+		{
+			name: "refresh_already_set", scopes: "offline_access,profile", clientID: "headlamp",
+			want: []string{"offline_access", "profile"},
+		},
+		{
+			name: "userinfo", scopes: "email", userInfo: true,
+			want: []string{"email", "profile"},
+		},
+		{
+			name: "groups_from_userinfo", scopes: "profile,email", userInfo: true, groupsFromUserInfo: true,
+			want: []string{"profile", "email", "groups"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := &config.Config{
+				OidcScopes:             tt.scopes,
+				OidcClientID:           tt.clientID,
+				OidcUserInfoEnabled:    tt.userInfo,
+				OidcGroupsFromUserInfo: tt.groupsFromUserInfo,
+			}
+
+			assert.Equal(t, tt.want, conf.EffectiveOidcScopes())
+		})
+	}
+}
+
+func TestEffectiveValidator(t *testing.T) {
+	tests := []struct {
+		name         string
+		conf         config.Config
+		wantIssuer   string
+		wantClientID string
+	}{
+		{
+			name:         "defaults_to_main_settings",
+			conf:         config.Config{OidcIdpIssuerURL: "https://idp.example.com", OidcClientID: "headlamp"},
+			wantIssuer:   "https://idp.example.com",
+			wantClientID: "headlamp",
+		},
+		{
+			name: "validator_overrides",
+			conf: config.Config{
+				OidcIdpIssuerURL:          "https://idp.example.com",
+				OidcValidatorIdpIssuerURL: "https://sts.example.com",
+				OidcClientID:              "headlamp",
+				OidcValidatorClientID:     "tenant-a,tenant-b",
+			},
+			wantIssuer:   "https://sts.example.com",
+			wantClientID: "tenant-a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantIssuer, tt.conf.EffectiveValidatorIssuer())
+			assert.Equal(t, tt.wantClientID, tt.conf.EffectiveValidatorClientID())
+		})
+	}
+}
+
+func TestOTLPExportEndpoint(t *testing.T) {
+	trueVal := true
+
+	tests := []struct {
+		name         string
+		endpoint     string
+		insecure     *bool
+		wantEndpoint string
+		wantInsecure bool
+	}{
+		{name: "host_port", endpoint: "otel-collector:4317", wantEndpoint: "otel-collector:4317", wantInsecure: true},
+		{name: "http", endpoint: "http://otel-collector:4318", wantEndpoint: "otel-collector:4318", wantInsecure: true},
+		{name: "https", endpoint: "https://otel.example.com:4318", wantEndpoint: "otel.example.com:4318"},
+		{
+			name:         "https_insecure",
+			endpoint:     "https://otel.example.com:4318",
+			insecure:     &trueVal,
+			wantEndpoint: "otel.example.com:4318",
+			wantInsecure: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := config.Config{OTLPEndpoint: &tt.endpoint, OTLPInsecure: tt.insecure}
+
+			endpoint, insecure := conf.OTLPExportEndpoint()
+			assert.Equal(t, tt.wantEndpoint, endpoint)
+			assert.Equal(t, tt.wantInsecure, insecure)
+		})
+	}
+}
+
+func TestKubeConfigPaths(t *testing.T) {
+	home, err := os.UserHomeDir()
+	require.NoError(t, err)
+
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+
+	sep := string(filepath.ListSeparator)
+	otherSep := ";"
+
+	if sep == ";" {
+		otherSep = ":"
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want []string
+	}{
+		{name: "empty", path: "", want: nil},
+		{name: "single_path", path: "/etc/kube/config", want: []string{filepath.Clean("/etc/kube/config")}},
+		{
+			name: "multiple_paths",
+			path: "/etc/kube/a" + sep + "/etc/kube/b",
+			want: []string{filepath.Clean("/etc/kube/a"), filepath.Clean("/etc/kube/b")},
+		},
+		{
+			name: "other_separator_is_part_of_the_path",
+			path: "/etc/kube/a" + otherSep + "b",
+			want: []string{filepath.Clean("/etc/kube/a" + otherSep + "b")},
+		},
+		{
+			name: "empty_segments",
+			path: sep + "/etc/kube/a" + sep + sep + " " + sep,
+			want: []string{filepath.Clean("/etc/kube/a")},
+		},
+		{
+			name: "home_and_relative_paths",
+			path: "~/.kube/config" + sep + "kube/config" + sep + "~",
+			want: []string{
+				filepath.Join(home, ".kube", "config"),
+				filepath.Join(cwd, "kube", "config"),
+				home,
+			},
+		},
+		{
+			name: "duplicates",
+			path: "/etc/kube/b" + sep + "/etc/kube/a" + sep + "/etc/kube/../kube/b" + sep + "/etc/kube/a",
+			want: []string{filepath.Clean("/etc/kube/b"), filepath.Clean("/etc/kube/a")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if filepath.Separator != '/' && strings.HasPrefix(tt.path, "/") {
+				t.Skip("absolute unix paths")
+			}
+
+			conf := config.Config{KubeConfigPath: tt.path}
+			assert.Equal(t, tt.want, conf.KubeConfigPaths())
+		})
+	}
+}
+
+func TestKubeConfigPathsDefault(t *testing.T) {
+	t.Setenv("KUBECONFIG", "")
+
+	conf, err := config.Parse(nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{config.GetDefaultKubeConfigPath()}, conf.KubeConfigPaths())
+}
+
+func TestDefaultContext(t *testing.T) {
+	withCurrent := writeConfigFile(t, `current-context: minikube
+contexts:
+- name: kind
+- name: minikube
+`)
+	withoutCurrent := writeConfigFile(t, `contexts:
+- name: kind
+- name: minikube
+`)
+
+	tests := []struct {
+		name     string
+		paths    []string
+		fallback string
+		skipped  string
+		want     string
+		wantErr  bool
+	}{
+		{name: "current_context", paths: []string{withCurrent}, fallback: "none", want: "minikube"},
+		{name: "none", paths: []string{withoutCurrent}, fallback: "none", want: ""},
+		{name: "first", paths: []string{withoutCurrent}, fallback: "first", want: "kind"},
+		{name: "first_not_skipped", paths: []string{withoutCurrent}, fallback: "first", skipped: "kind", want: "minikube"},
+		{name: "skipped_current_context", paths: []string{withCurrent}, fallback: "none", skipped: "minikube", want: ""},
+		{
+			name: "skipped_current_context_fallback", paths: []string{withCurrent}, fallback: "first", skipped: "minikube",
+			want: "kind",
+		},
+		{name: "name", paths: []string{withoutCurrent}, fallback: "minikube", want: "minikube"},
+		{name: "unknown_name", paths: []string{withoutCurrent}, fallback: "eks", wantErr: true},
+		{name: "later_current_context", paths: []string{withoutCurrent, withCurrent}, fallback: "first", want: "minikube"},
+		{name: "missing_file", paths: []string{filepath.Join(t.TempDir(), "missing")}, fallback: "first", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := &config.Config{
+				KubeConfigPath:         strings.Join(tt.paths, string(filepath.ListSeparator)),
+				DefaultContextFallback: tt.fallback,
+				SkippedKubeContexts:    tt.skipped,
+			}
+
+			got, err := conf.DefaultContext()
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseRanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		errorMsg string
+	}{
+		{name: "default_port", args: nil},
+		{name: "min_port", args: []string{"--port=1"}},
+		{name: "max_port", args: []string{"--port=65535"}},
+		{name: "zero_port", args: []string{"--port=0"}, errorMsg: "port 0 needs to be between 1 and 65535"},
+		{name: "too_large_port", args: []string{"--port=65536"}, errorMsg: "port 65536 needs to be between 1 and 65535"},
+		{name: "default_sampling_rate", args: []string{"--tracing-enabled"}},
+		{name: "min_sampling_rate", args: []string{"--tracing-enabled", "--sampling-rate=0"}},
+		{name: "max_sampling_rate", args: []string{"--tracing-enabled", "--sampling-rate=1.0"}},
+		{
+			name:     "negative_sampling_rate",
+			args:     []string{"--tracing-enabled", "--sampling-rate=-0.1"},
+			errorMsg: "sampling-rate -0.1 needs to be between 0.0 and 1.0",
+		},
+		{
+			name:     "too_large_sampling_rate",
+			args:     []string{"--tracing-enabled", "--sampling-rate=5.0"},
+			errorMsg: "sampling-rate 5 needs to be between 0.0 and 1.0",
+		},
+		{name: "sampling_rate_without_tracing", args: []string{"--sampling-rate=5.0"}},
+		{name: "hostname_listen_addr", args: []string{"--listen-addr=localhost"}},
+		{name: "ipv4_listen_addr", args: []string{"--listen-addr=127.0.0.1"}},
+		{name: "ipv6_listen_addr", args: []string{"--listen-addr=[::1]"}},
+		{
+			name:     "unbracketed_ipv6_listen_addr",
+			args:     []string{"--listen-addr=::1"},
+			errorMsg: "listen-addr \"::1\" needs to be in brackets",
+		},
+		{
+			name:     "invalid_ipv6_listen_addr",
+			args:     []string{"--listen-addr=[::1"},
+			errorMsg: "listen-addr \"[::1\" is not a valid IPv6 address",
+		},
+		{
+			name:     "listen_addr_with_port",
+			args:     []string{"--listen-addr=localhost:4466"},
+			errorMsg: "listen-addr \"localhost:4466\" needs to be a hostname or an IP address",
+		},
+		{
+			name: "oidc_discovery_cache_ttl",
+			args: []string{
+				"--in-cluster", "--oidc-client-id=headlamp", "--oidc-idp-issuer-url=https://idp.example.com",
+				"--oidc-discovery-cache-ttl=10m",
+			},
+		},
+		{
+			name:     "negative_oidc_discovery_cache_ttl",
+			args:     []string{"--oidc-discovery-cache-ttl=-1s"},
+			errorMsg: "oidc-discovery-cache-ttl needs to be positive",
+		},
+		{name: "global_request_timeout", args: []string{"--global-request-timeout=30s"}},
+		{
+			name:     "negative_global_request_timeout",
+			args:     []string{"--global-request-timeout=-1s"},
+			errorMsg: "global-request-timeout needs to be positive",
+		},
+		{name: "api_idle_conns", args: []string{"--api-max-idle-conns=200", "--api-max-idle-conns-per-host=50"}},
+		{
+			name:     "negative_api_idle_conns",
+			args:     []string{"--api-max-idle-conns=-1"},
+			errorMsg: "api-max-idle-conns needs to be positive",
+		},
+		{
+			name:     "negative_api_idle_conns_per_host",
+			args:     []string{"--api-max-idle-conns-per-host=-1"},
+			errorMsg: "api-max-idle-conns-per-host needs to be positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := config.Parse(append([]string{"go run ./cmd"}, tt.args...))
+			if tt.errorMsg != "" {
+				require.Error(t, err)
+				require.Nil(t, conf)
+				assert.Contains(t, err.Error(), tt.errorMsg)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, conf)
+		})
+	}
+}
+
+func TestValidateNilSamplingRate(t *testing.T) {
+	conf, err := config.Parse([]string{"go run ./cmd", "--tracing-enabled"})
+	require.NoError(t, err)
+
+	conf.SamplingRate = nil
+
+	assert.NotPanics(t, func() {
+		assert.NoError(t, conf.Validate())
+	})
+}
+
+func TestProxyURLPatterns(t *testing.T) {
+	t.Run("trims_and_dedupes", func(t *testing.T) {
+		conf := &config.Config{
+			ProxyURLs: " https://artifacthub.io/*, ,https://*.example.com:8443/*,https://artifacthub.io/*,",
+		}
+
+		patterns, err := conf.ProxyURLPatterns()
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"https://artifacthub.io/*", "https://*.example.com:8443/*"}, patterns)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		patterns, err := (&config.Config{ProxyURLs: ","}).ProxyURLPatterns()
+		require.NoError(t, err)
+
+		assert.Empty(t, patterns)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, proxyURLs := range []string{
+			"artifacthub.io",
+			"https://artifacthub.io/*,/relative/*",
+			"https://[example.com/*",
+			"://example.com",
+		} {
+			conf, err := config.Parse([]string{"go run ./cmd", "--proxy-urls=" + proxyURLs})
+
+			require.Error(t, err, proxyURLs)
+			require.Nil(t, conf)
+
+			assert.Contains(t, err.Error(), "proxy-urls", proxyURLs)
+		}
+	})
+}
+
+func TestIsProxyAllowed(t *testing.T) {
+	conf := &config.Config{
+		ProxyURLs: "https://artifacthub.io/*,https://*.example.com/*,http://localhost:8080/api," +
+			"https://charts.example.org/*/index.yaml",
+	}
+
+	tests := []struct {
+		target  string
+		allowed bool
+	}{
+		{target: "https://artifacthub.io/api/v1/packages", allowed: true},
+		{target: "https://charts.example.com/index.yaml", allowed: true},
+		{target: "http://localhost:8080/api", allowed: true},
+		{target: "http://artifacthub.io/api", allowed: false},
+		{target: "https://example.com/index.yaml", allowed: false},
+		{target: "https://charts.example.com:8443/index.yaml", allowed: false},
+		{target: "http://localhost:9090/api", allowed: false},
+		{target: "http://localhost:8080/api/other", allowed: false},
+		{target: "https://artifacthub.io/api/v1/packages?limit=5", allowed: true},
+		{target: "https://CHARTS.example.com/index.yaml", allowed: true},
+		{target: "https://charts.example.org/stable/index.yaml", allowed: true},
+		{target: "https://charts.example.org/stable/nested/index.yaml", allowed: false},
+		// The host of the pattern in the path, query or fragment of another host.
+		{target: "https://evil.com/.example.com/x", allowed: false},
+		{target: "https://evil.com?.example.com/", allowed: false},
+		{target: "https://attacker.net#.example.com/", allowed: false},
+		{target: "https://charts.example.com@evil.com/index.yaml", allowed: false},
+		// A wildcard in the host does not cross dots.
+		{target: "https://evil.com.example.com.attacker.net/x", allowed: false},
+		{target: "", allowed: false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.allowed, conf.IsProxyAllowed(tt.target), tt.target)
+	}
+
+	assert.False(t, (&config.Config{ProxyURLs: "artifacthub.io"}).IsProxyAllowed("artifacthub.io"))
+}
+
+func TestSkippedContexts(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		conf := &config.Config{}
+
+		assert.Empty(t, conf.SkippedContexts())
+		assert.False(t, conf.IsContextSkipped(""))
+		assert.False(t, conf.IsContextSkipped("prod"))
+	})
+
+	t.Run("trims_and_dedupes", func(t *testing.T) {
+		conf := &config.Config{SkippedKubeContexts: " prod , staging,,prod, "}
+
+		assert.Equal(t, map[string]struct{}{"prod": {}, "staging": {}}, conf.SkippedContexts())
+		assert.True(t, conf.IsContextSkipped("prod"))
+		assert.True(t, conf.IsContextSkipped("staging"))
+		assert.False(t, conf.IsContextSkipped(""))
+	})
+
+	t.Run("exact_match", func(t *testing.T) {
+		conf := &config.Config{SkippedKubeContexts: "prod"}
+
+		assert.False(t, conf.IsContextSkipped("prod-eu"))
+		assert.False(t, conf.IsContextSkipped("pro"))
+		assert.False(t, conf.IsContextSkipped("Prod"))
+	})
+}
+
+func TestNewConfig(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv("KUBECONFIG", "")
+
+		parsed, err := config.Parse(nil)
+		require.NoError(t, err)
+
+		conf, err := config.NewConfig(nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, parsed, conf)
+		assert.Equal(t, config.GetDefaultKubeConfigPath(), conf.KubeConfigPath)
+	})
+
+	t.Run("ignores_env", func(t *testing.T) {
+		t.Setenv("HEADLAMP_CONFIG_PORT", "1234")
+		t.Setenv("KUBECONFIG", "/tmp/kubeconfig")
+
+		conf, err := config.NewConfig(nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, uint(4466), conf.Port)
+		assert.Equal(t, config.GetDefaultKubeConfigPath(), conf.KubeConfigPath)
+	})
+
+	t.Run("overrides", func(t *testing.T) {
+		conf, err := config.NewConfig(map[string]interface{}{
+			"port":            3456,
+			"kubeconfig":      "/tmp/kubeconfig",
+			"enable-helm":     true,
+			"metrics-enabled": true,
+			"startup-timeout": "1m",
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, uint(3456), conf.Port)
+		assert.Equal(t, "/tmp/kubeconfig", conf.KubeConfigPath)
+		assert.True(t, conf.EnableHelm)
+		require.NotNil(t, conf.MetricsEnabled)
+		assert.True(t, *conf.MetricsEnabled)
+		assert.Equal(t, time.Minute, conf.StartupTimeout)
+	})
+
+	t.Run("in_cluster", func(t *testing.T) {
+		conf, err := config.NewConfig(map[string]interface{}{"in-cluster": true})
+		require.NoError(t, err)
+
+		assert.True(t, conf.InCluster)
+		assert.False(t, conf.WatchPluginsChanges)
+		assert.Empty(t, conf.KubeConfigPath)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		conf, err := config.NewConfig(map[string]interface{}{"port": 0})
+		require.Error(t, err)
+		assert.Nil(t, conf)
+
+		assert.Contains(t, err.Error(), "port 0")
+	})
+
+	t.Run("unknown_keys", func(t *testing.T) {
+		conf, err := config.NewConfig(map[string]interface{}{"prot": 3456, "dve": true})
+		require.Error(t, err)
+		assert.Nil(t, conf)
+
+		assert.EqualError(t, err, "unknown config keys: dve, prot")
+	})
+}
+
+func TestCookieEncryptionKeyBytes(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		wantLen  int
+		errorMsg string
+	}{
+		{name: "empty", key: "", wantLen: 0},
+		{name: "hex", key: strings.Repeat("ab", 32), wantLen: 32},
+		{name: "base64", key: base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32)), wantLen: 32},
+		{
+			name:     "hex_too_short",
+			key:      strings.Repeat("ab", 16),
+			errorMsg: "decodes from hex to 16 bytes, expected exactly 32 bytes (64 hex characters)",
+		},
+		{
+			name:     "hex_too_long",
+			key:      strings.Repeat("ab", 48),
+			errorMsg: "decodes from hex to 48 bytes, expected exactly 32 bytes (64 hex characters)",
+		},
+		{
+			name:     "base64_too_short",
+			key:      base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 16)),
+			errorMsg: "decodes from base64 to 16 bytes, expected exactly 32 bytes (44 base64 characters)",
+		},
+		{
+			name:     "base64_too_long",
+			key:      base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 64)),
+			errorMsg: "decodes from base64 to 64 bytes, expected exactly 32 bytes (44 base64 characters)",
+		},
+		{
+			name:     "bad_encoding",
+			key:      "not a key!",
+			errorMsg: "is neither hex nor base64 encoded: expected a 32 byte key as 64 hex or 44 base64 characters",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := &config.Config{CookieEncryptionKey: tt.key}
+
+			key, err := conf.CookieEncryptionKeyBytes()
+			if tt.errorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorMsg)
+				assert.Contains(t, conf.Validate().Error(), tt.errorMsg)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Len(t, key, tt.wantLen)
+		})
+	}
 }
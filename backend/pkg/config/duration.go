@@ -0,0 +1,103 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/kubernetes-sigs/headlamp/backend/pkg/logger"
+)
+
+// parseDuration parses the value of the duration flag name, e.g. 30s or 1m30s.
+// As other tools accept it, a bare integer is read as seconds, with a warning
+// recommending an explicit unit.
+func parseDuration(name, value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds != 0 {
+			logger.Log(logger.LevelWarn, map[string]string{"flag": name, "value": value}, nil,
+				fmt.Sprintf("%s has no unit, reading it as seconds; use an explicit unit like %ss", name, value))
+		}
+
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid duration %q, expected a value like 30s or 1m30s", name, value)
+	}
+
+	return duration, nil
+}
+
+// durationValue is a flag.Value for durations parsed with parseDuration.
+type durationValue struct {
+	name     string
+	duration *time.Duration
+}
+
+// Set implements flag.Value.
+func (d durationValue) Set(value string) error {
+	duration, err := parseDuration(d.name, value)
+	if err != nil {
+		return err
+	}
+
+	*d.duration = duration
+
+	return nil
+}
+
+// String implements flag.Value.
+func (d durationValue) String() string {
+	if d.duration == nil {
+		return ""
+	}
+
+	return d.duration.String()
+}
+
+// durationFlag defines a duration flag that also accepts bare seconds.
+func durationFlag(f *flag.FlagSet, name string, value time.Duration, usage string) {
+	duration := value
+	f.Var(durationValue{name: name, duration: &duration}, name, usage)
+}
+
+// normalizeDurations rewrites the values of the duration flags loaded from the
+// env or a config file, so bare seconds are read the same way as on the
+// command line.
+func normalizeDurations(k *koanf.Koanf, f *flag.FlagSet) error {
+	values := map[string]interface{}{}
+
+	var err error
+
+	f.VisitAll(func(fl *flag.Flag) {
+		if _, ok := fl.Value.(durationValue); !ok || err != nil || !k.Exists(fl.Name) {
+			return
+		}
+
+		var duration time.Duration
+
+		switch value := k.Get(fl.Name).(type) {
+		case string:
+			duration, err = parseDuration(fl.Name, value)
+		case int, int64, float64:
+			duration, err = parseDuration(fl.Name, fmt.Sprint(value))
+		default:
+			err = fmt.Errorf("%s: invalid duration %v, expected a value like 30s or 1m30s", fl.Name, value)
+		}
+
+		values[fl.Name] = duration.String()
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return k.Load(confmap.Provider(values, "."), nil)
+}
@@ -0,0 +1,77 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kubernetes-sigs/headlamp/backend/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDurationFlags(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "30", want: 30 * time.Second},
+		{value: "30s", want: 30 * time.Second},
+		{value: "1m30s", want: 90 * time.Second},
+		{value: "0", want: 0},
+		{value: "", wantErr: true},
+		{value: "1.5", wantErr: true},
+		{value: "30x", wantErr: true},
+		{value: "thirty", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			conf, err := config.Parse([]string{"go run ./cmd", "--startup-timeout=" + tt.value})
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Nil(t, conf)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, conf.StartupTimeout)
+		})
+	}
+}
+
+func TestParseDurationFromEnvAndFile(t *testing.T) {
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("HEADLAMP_CONFIG_WATCH_DRAIN_TIMEOUT", "45")
+
+		conf, err := config.Parse(nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, 45*time.Second, conf.WatchDrainTimeout)
+		assert.Equal(t, time.Minute, conf.ProxyURLsRefresh)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		configFile := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(configFile, []byte("proxy-urls-refresh: 90\nstartup-timeout: 1m30s\n"), 0o600))
+
+		conf, err := config.Parse([]string{"go run ./cmd", "--config-file=" + configFile})
+		require.NoError(t, err)
+
+		assert.Equal(t, 90*time.Second, conf.ProxyURLsRefresh)
+		assert.Equal(t, 90*time.Second, conf.StartupTimeout)
+	})
+
+	t.Run("invalid_env", func(t *testing.T) {
+		t.Setenv("HEADLAMP_CONFIG_WATCH_DRAIN_TIMEOUT", "soon")
+
+		conf, err := config.Parse(nil)
+		require.Error(t, err)
+		assert.Nil(t, conf)
+
+		assert.Contains(t, err.Error(), "watch-drain-timeout")
+	})
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/knadh/koanf"
+	kyaml "github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/basicflag"
+	"github.com/knadh/koanf/providers/env"
+	"github.com/knadh/koanf/providers/file"
+)
+
+// Loader reads the config sources for ParseWithLoader. Each method returns
+// the values it found, keyed by flag name.
+type Loader interface {
+	// Defaults returns the default value of every flag in f.
+	Defaults(f *flag.FlagSet) (map[string]interface{}, error)
+	// Env returns the values set in the environment.
+	Env() (map[string]interface{}, error)
+	// File returns the values set in the config file at path.
+	File(path string) (map[string]interface{}, error)
+	// Flags returns the values of the flags in f set on the command line,
+	// which are the ones in explicit.
+	Flags(f *flag.FlagSet, explicit map[string]bool) (map[string]interface{}, error)
+	// KubeConfigEnv returns the kubeconfig paths used when neither kubeconfig
+	// nor in-cluster is set, like the KUBECONFIG env var.
+	KubeConfigEnv() string
+}
+
+// KoanfLoader is the Loader used by Parse. It reads HEADLAMP_CONFIG_ env
+// vars, YAML config files and command line flags.
+type KoanfLoader struct{}
+
+// Defaults returns the default value of every flag in f.
+func (KoanfLoader) Defaults(f *flag.FlagSet) (map[string]interface{}, error) {
+	return load(basicflag.Provider(f, "."), nil)
+}
+
+// Env returns the values of the HEADLAMP_CONFIG_ env vars, with the prefix
+// removed and _ replaced by -, e.g. HEADLAMP_CONFIG_PORT sets port.
+func (KoanfLoader) Env() (map[string]interface{}, error) {
+	return load(env.Provider(envPrefix, ".", func(s string) string {
+		return strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(s, envPrefix)), "_", "-")
+	}), nil)
+}
+
+// File returns the values set in the YAML config file at path.
+func (KoanfLoader) File(path string) (map[string]interface{}, error) {
+	return load(file.Provider(path), kyaml.Parser())
+}
+
+// Flags returns the values of the flags in f that are in explicit.
+func (KoanfLoader) Flags(f *flag.FlagSet, explicit map[string]bool) (map[string]interface{}, error) {
+	return load(basicflag.ProviderWithValue(f, ".", func(key string, value string) (string, interface{}) {
+		if explicit[key] {
+			return key, value
+		}
+		return "", nil
+	}), nil)
+}
+
+// KubeConfigEnv returns the value of the KUBECONFIG env var.
+func (KoanfLoader) KubeConfigEnv() string {
+	return os.Getenv("KUBECONFIG")
+}
+
+// load reads the values of a koanf provider.
+func load(provider koanf.Provider, parser koanf.Parser) (map[string]interface{}, error) {
+	k := koanf.New(".")
+
+	if err := k.Load(provider, parser); err != nil {
+		return nil, err
+	}
+
+	return k.Raw(), nil
+}
@@ -0,0 +1,126 @@
+package config_test
+
+import (
+	"errors"
+	"flag"
+	"testing"
+
+	"github.com/kubernetes-sigs/headlamp/backend/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLoader returns fixed values for the env and config file, on top of the
+// flags read by config.KoanfLoader.
+type fakeLoader struct {
+	config.KoanfLoader
+	env           map[string]interface{}
+	file          map[string]interface{}
+	fileErr       error
+	filePath      *string
+	kubeConfigEnv string
+}
+
+func (l fakeLoader) Env() (map[string]interface{}, error) {
+	return l.env, nil
+}
+
+func (l fakeLoader) File(path string) (map[string]interface{}, error) {
+	if l.filePath != nil {
+		*l.filePath = path
+	}
+
+	return l.file, l.fileErr
+}
+
+func (l fakeLoader) KubeConfigEnv() string {
+	return l.kubeConfigEnv
+}
+
+func TestParseWithLoader(t *testing.T) {
+	t.Run("fake_sources", func(t *testing.T) {
+		loader := fakeLoader{
+			env:  map[string]interface{}{"port": "3456", "base-url": "/env"},
+			file: map[string]interface{}{"base-url": "/file", "api-max-idle-conns": 100},
+		}
+
+		conf, err := config.ParseWithLoader([]string{"go run ./cmd", "--config-file=headlamp.yaml"}, loader)
+		require.NoError(t, err)
+
+		assert.Equal(t, uint(3456), conf.Port)
+		assert.Equal(t, "/env", conf.BaseURL)
+		assert.Equal(t, 100, conf.APIMaxIdleConns)
+	})
+
+	t.Run("flags_take_priority", func(t *testing.T) {
+		loader := fakeLoader{env: map[string]interface{}{"port": "3456"}}
+
+		conf, err := config.ParseWithLoader([]string{"go run ./cmd", "--port=5678"}, loader)
+		require.NoError(t, err)
+
+		assert.Equal(t, uint(5678), conf.Port)
+	})
+
+	t.Run("bootstrap_values_from_env", func(t *testing.T) {
+		t.Setenv("HEADLAMP_CONFIG_CONFIG_FILE", "real.yaml")
+
+		var filePath string
+
+		loader := fakeLoader{
+			env:      map[string]interface{}{"config-file": "fake.yaml", "config-precedence": "file,env,flag"},
+			file:     map[string]interface{}{"port": 3456},
+			filePath: &filePath,
+		}
+
+		conf, err := config.ParseWithLoader([]string{"go run ./cmd", "--port=5678"}, loader)
+		require.NoError(t, err)
+
+		assert.Equal(t, "fake.yaml", filePath)
+		// config-precedence from the env puts the file above the flags.
+		assert.Equal(t, uint(3456), conf.Port)
+	})
+
+	t.Run("kubeconfig_env", func(t *testing.T) {
+		t.Setenv("KUBECONFIG", "/real/config")
+
+		loader := fakeLoader{kubeConfigEnv: "/fake/config"}
+
+		conf, err := config.ParseWithLoader([]string{"go run ./cmd"}, loader)
+		require.NoError(t, err)
+
+		assert.Equal(t, "/fake/config", conf.KubeConfigPath)
+	})
+
+	t.Run("unknown_file_keys", func(t *testing.T) {
+		loader := fakeLoader{file: map[string]interface{}{"no-such-flag": true}}
+
+		conf, err := config.ParseWithLoader([]string{"go run ./cmd", "--config-file=headlamp.yaml"}, loader)
+		require.Error(t, err)
+		require.Nil(t, conf)
+
+		assert.Contains(t, err.Error(), "no-such-flag")
+	})
+
+	t.Run("loader_error", func(t *testing.T) {
+		loader := fakeLoader{fileErr: errors.New("unreachable")}
+
+		conf, err := config.ParseWithLoader([]string{"go run ./cmd", "--config-file=headlamp.yaml"}, loader)
+		require.Error(t, err)
+		require.Nil(t, conf)
+
+		assert.Contains(t, err.Error(), "error loading config from file: unreachable")
+	})
+}
+
+func TestKoanfLoaderFlags(t *testing.T) {
+	f := flag.NewFlagSet("test", flag.ContinueOnError)
+	f.String("set", "default", "")
+	f.String("unset", "default", "")
+
+	require.NoError(t, f.Parse([]string{"--set=value"}))
+
+	values, err := config.KoanfLoader{}.Flags(f, map[string]bool{"set": true})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{"set": "value"}, values)
+}
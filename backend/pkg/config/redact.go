@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// redactedValue replaces the value of secret fields in Redacted.
+const redactedValue = "***"
+
+// secretFields returns the fields of a Config that must not be logged. Add a
+// field here to have Redacted and String mask it.
+var secretFields = []func(c *Config) *string{
+	func(c *Config) *string { return &c.OidcClientSecret },
+	func(c *Config) *string { return &c.CookieEncryptionKey },
+	func(c *Config) *string { return &c.KubeConfigBase64 },
+	// Added headers can carry credentials, e.g. an Authorization header.
+	func(c *Config) *string { return &c.ProxyAddRequestHeaders },
+}
+
+// Redacted returns a copy of the config with the secret fields that are set
+// replaced by "***", so it can be logged. Unset secrets stay empty.
+func (c *Config) Redacted() *Config {
+	redacted := *c
+
+	for _, field := range secretFields {
+		if value := field(&redacted); *value != "" {
+			*value = redactedValue
+		}
+	}
+
+	return &redacted
+}
+
+// String formats the config with its secrets redacted, as a list of
+// key=value pairs using the flag names as keys.
+func (c Config) String() string {
+	redacted := reflect.ValueOf(c.Redacted()).Elem()
+	fields := make([]string, 0, redacted.NumField())
+
+	for i := range redacted.NumField() {
+		key := redacted.Type().Field(i).Tag.Get("koanf")
+		if key == "" {
+			continue
+		}
+
+		value := redacted.Field(i)
+		if value.Kind() == reflect.Pointer {
+			if value.IsNil() {
+				fields = append(fields, key+"=<nil>")
+
+				continue
+			}
+
+			value = value.Elem()
+		}
+
+		fields = append(fields, fmt.Sprintf("%s=%v", key, value.Interface()))
+	}
+
+	return "{" + strings.Join(fields, " ") + "}"
+}
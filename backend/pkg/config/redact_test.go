@@ -0,0 +1,45 @@
+package config_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/kubernetes-sigs/headlamp/backend/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedacted(t *testing.T) {
+	serviceName := "headlamp-test"
+	conf := &config.Config{
+		OidcClientID:           "headlamp",
+		OidcClientSecret:       "client-secret-value",
+		CookieEncryptionKey:    "cookie-key-value",
+		ProxyAddRequestHeaders: "Authorization=Bearer header-token-value",
+		ServiceVersion:         &serviceName,
+	}
+
+	redacted := conf.Redacted()
+
+	assert.Equal(t, "***", redacted.OidcClientSecret)
+	assert.Equal(t, "***", redacted.CookieEncryptionKey)
+	assert.Equal(t, "***", redacted.ProxyAddRequestHeaders)
+	assert.Equal(t, "headlamp", redacted.OidcClientID)
+
+	// Unset secrets stay empty, and the original is unchanged.
+	assert.Equal(t, "", redacted.KubeConfigBase64)
+	assert.Equal(t, "client-secret-value", conf.OidcClientSecret)
+
+	for _, format := range []string{"%v", "%+v", "%s"} {
+		for _, value := range []interface{}{conf, *conf} {
+			formatted := fmt.Sprintf(format, value)
+
+			assert.NotContains(t, formatted, "client-secret-value")
+			assert.NotContains(t, formatted, "cookie-key-value")
+			assert.NotContains(t, formatted, "header-token-value")
+			assert.Contains(t, formatted, "oidc-client-secret=***")
+			assert.Contains(t, formatted, "kubeconfig-base64= ")
+			assert.Contains(t, formatted, "service-version=headlamp-test")
+			assert.Contains(t, formatted, "tracing-enabled=<nil>")
+		}
+	}
+}
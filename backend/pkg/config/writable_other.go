@@ -0,0 +1,17 @@
+//go:build !windows
+
+package config
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// accessWriteOK is the W_OK mode of access(2).
+const accessWriteOK = 0x2
+
+// isDirWritable returns true if the process can create files in the
+// directory at path, without creating any.
+func isDirWritable(path string, _ fs.FileInfo) bool {
+	return syscall.Access(path, accessWriteOK) == nil
+}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package config
+
+import (
+	"io/fs"
+)
+
+// isDirWritable returns true if the directory at path is not read-only. ACLs
+// are not checked, as Windows has no access(2).
+func isDirWritable(_ string, info fs.FileInfo) bool {
+	return info.Mode().Perm()&0o200 != 0
+}
@@ -19,20 +19,49 @@ type ContextStore interface {
 }
 
 type contextStore struct {
-	cache cache.Cache[*Context]
+	cache          cache.Cache[*Context]
+	idleConnLimits IdleConnLimits
 }
 
 // NewContextStore creates a new ContextStore.
 func NewContextStore() ContextStore {
+	return NewContextStoreWithIdleConnLimits(IdleConnLimits{})
+}
+
+// NewContextStoreWithIdleConnLimits creates a new ContextStore whose contexts
+// proxy requests with the given idle connection limits.
+func NewContextStoreWithIdleConnLimits(limits IdleConnLimits) ContextStore {
 	cache := cache.New[*Context]()
 
 	return &contextStore{
-		cache: cache,
+		cache:          cache,
+		idleConnLimits: limits,
 	}
 }
 
+// applyIdleConnLimits sets the idle connection limits of the store on
+// headlampContext. A proxy already set up with other limits is set up again
+// with these.
+func (c *contextStore) applyIdleConnLimits(headlampContext *Context) error {
+	if headlampContext.idleConnLimits == c.idleConnLimits {
+		return nil
+	}
+
+	headlampContext.idleConnLimits = c.idleConnLimits
+
+	if headlampContext.proxy == nil {
+		return nil
+	}
+
+	return headlampContext.SetupProxy()
+}
+
 // AddContext adds a context to the store.
 func (c *contextStore) AddContext(headlampContext *Context) error {
+	if err := c.applyIdleConnLimits(headlampContext); err != nil {
+		return err
+	}
+
 	name := headlampContext.Name
 
 	if headlampContext.KubeContext != nil && headlampContext.KubeContext.Extensions["headlamp_info"] != nil {
@@ -93,6 +122,10 @@ func (c *contextStore) RemoveContext(name string) error {
 
 // AddContextWithKeyAndTTL adds a context to the store with a ttl.
 func (c *contextStore) AddContextWithKeyAndTTL(headlampContext *Context, key string, ttl time.Duration) error {
+	if err := c.applyIdleConnLimits(headlampContext); err != nil {
+		return err
+	}
+
 	return c.cache.SetWithTTL(context.Background(), key, headlampContext, ttl)
 }
 
@@ -0,0 +1,106 @@
+package kubeconfig
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rest "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestMakeTransportForIdleConnLimits(t *testing.T) {
+	conf := &rest.Config{
+		Host:            "https://127.0.0.1:6443",
+		TLSClientConfig: rest.TLSClientConfig{Insecure: true},
+	}
+
+	shared, err := rest.TransportFor(conf)
+	require.NoError(t, err)
+
+	sharedTransport, ok := shared.(*http.Transport)
+	require.True(t, ok)
+
+	sharedLimits := IdleConnLimits{sharedTransport.MaxIdleConns, sharedTransport.MaxIdleConnsPerHost}
+
+	t.Run("defaults", func(t *testing.T) {
+		rt, err := makeTransportFor(conf, IdleConnLimits{})
+		require.NoError(t, err)
+
+		// Without limits, the transport is shared through the client-go cache.
+		assert.Same(t, shared, rt)
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		rt, err := makeTransportFor(conf, IdleConnLimits{MaxIdleConns: 200, MaxIdleConnsPerHost: 50})
+		require.NoError(t, err)
+
+		limited, ok := rt.(*http.Transport)
+		require.True(t, ok)
+		assert.NotSame(t, sharedTransport, limited)
+		assert.Equal(t, 200, limited.MaxIdleConns)
+		assert.Equal(t, 50, limited.MaxIdleConnsPerHost)
+		assert.True(t, limited.TLSClientConfig.InsecureSkipVerify)
+
+		// The shared transport keeps its limits.
+		assert.Equal(t, sharedLimits,
+			IdleConnLimits{sharedTransport.MaxIdleConns, sharedTransport.MaxIdleConnsPerHost})
+
+		other, err := makeTransportFor(conf, IdleConnLimits{MaxIdleConns: 200, MaxIdleConnsPerHost: 50})
+		require.NoError(t, err)
+		assert.NotSame(t, rt, other)
+	})
+
+	t.Run("default_per_host", func(t *testing.T) {
+		rt, err := makeTransportFor(conf, IdleConnLimits{MaxIdleConns: 100})
+		require.NoError(t, err)
+
+		limited, ok := rt.(*http.Transport)
+		require.True(t, ok)
+		assert.Equal(t, 100, limited.MaxIdleConns)
+		assert.Equal(t, sharedTransport.MaxIdleConnsPerHost, limited.MaxIdleConnsPerHost)
+	})
+
+	t.Run("http_proxy", func(t *testing.T) {
+		proxyURL, err := url.Parse("http://proxy.example.com:3128")
+		require.NoError(t, err)
+
+		proxyConf := rest.CopyConfig(conf)
+		proxyConf.Proxy = http.ProxyURL(proxyURL)
+
+		rt, err := makeTransportFor(proxyConf, IdleConnLimits{MaxIdleConnsPerHost: 50})
+		require.NoError(t, err)
+
+		limited, ok := rt.(*http.Transport)
+		require.True(t, ok)
+		assert.Equal(t, 50, limited.MaxIdleConnsPerHost)
+
+		// The HTTP proxy of the config is kept.
+		got, err := limited.Proxy(httptest.NewRequest(http.MethodGet, conf.Host, nil))
+		require.NoError(t, err)
+		assert.Equal(t, proxyURL, got)
+	})
+}
+
+func TestContextStoreIdleConnLimits(t *testing.T) {
+	limits := IdleConnLimits{MaxIdleConns: 200, MaxIdleConnsPerHost: 50}
+	store := NewContextStoreWithIdleConnLimits(limits)
+
+	headlampContext := &Context{Name: "minikube", Cluster: &api.Cluster{Server: "https://127.0.0.1:6443"}}
+	require.NoError(t, headlampContext.SetupProxy())
+	require.NotNil(t, headlampContext.proxy)
+
+	require.NoError(t, store.AddContext(headlampContext))
+
+	// The proxy set up without the limits is set up again with them.
+	assert.Equal(t, limits, headlampContext.idleConnLimits)
+	require.NotNil(t, headlampContext.proxy)
+
+	limited, ok := headlampContext.proxy.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.Equal(t, 200, limited.MaxIdleConns)
+	assert.Equal(t, 50, limited.MaxIdleConnsPerHost)
+}
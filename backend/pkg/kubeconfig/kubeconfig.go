@@ -49,6 +49,9 @@ type Context struct {
 	KubeConfigPath string `json:"kubeConfigPath"`
 	// ClusterID is the unique identifier for the cluster, consisting of the filepath and context name.
 	ClusterID string `json:"clusterID"`
+	// idleConnLimits are the limits of the transport of proxy, set by the
+	// ContextStore the context is added to.
+	idleConnLimits IdleConnLimits
 }
 
 type OidcConfig struct {
@@ -192,15 +195,53 @@ func (c *Context) RESTConfig() (*rest.Config, error) {
 	return clientConfig.ClientConfig()
 }
 
+// IdleConnLimits are the idle connection limits of the transport a context
+// proxies requests to its API server with. 0 keeps the client-go defaults,
+// which are no limit in total and 25 per host.
+type IdleConnLimits struct {
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+}
+
+// wrapTransport returns a transport wrapper that applies the limits to a
+// copy of the base transport of client-go, leaving its other settings, like
+// the HTTP proxy, as they are. The base transport is shared through the
+// client-go TLS cache, so the limits must not be set on it.
+func (l IdleConnLimits) wrapTransport(rt http.RoundTripper) http.RoundTripper {
+	base, ok := rt.(*http.Transport)
+	if !ok {
+		return rt
+	}
+
+	limited := base.Clone()
+
+	if l.MaxIdleConns > 0 {
+		limited.MaxIdleConns = l.MaxIdleConns
+	}
+
+	if l.MaxIdleConnsPerHost > 0 {
+		limited.MaxIdleConnsPerHost = l.MaxIdleConnsPerHost
+	}
+
+	return limited
+}
+
 // makeTransportFor creates an HTTP transport configuration with special handling for
 // Windows systems to prevent terminal window flashing during exec-based authentication.
-func makeTransportFor(conf *rest.Config) (http.RoundTripper, error) {
+func makeTransportFor(conf *rest.Config, limits IdleConnLimits) (http.RoundTripper, error) {
 	if conf == nil {
 		return nil, fmt.Errorf("configuration cannot be nil")
 	}
 
 	// Use standard transport for non-Windows systems or when ExecProvider is not configured
 	if conf.ExecProvider == nil || runtime.GOOS != "windows" {
+		if limits != (IdleConnLimits{}) {
+			limitedConf := rest.CopyConfig(conf)
+			limitedConf.WrapTransport = transport.Wrappers(limits.wrapTransport, conf.WrapTransport)
+
+			return rest.TransportFor(limitedConf)
+		}
+
 		return rest.TransportFor(conf)
 	}
 
@@ -234,6 +275,10 @@ func makeTransportFor(conf *rest.Config) (http.RoundTripper, error) {
 		return nil, fmt.Errorf("failed to update transport config: %w", err)
 	}
 
+	if limits != (IdleConnLimits{}) {
+		cfg.WrapTransport = transport.Wrappers(limits.wrapTransport, cfg.WrapTransport)
+	}
+
 	return transport.New(cfg)
 }
 
@@ -308,7 +353,7 @@ func (c *Context) SetupProxy() error {
 
 	restConf, err := c.RESTConfig()
 	if err == nil {
-		roundTripper, err := makeTransportFor(restConf)
+		roundTripper, err := makeTransportFor(restConf, c.idleConnLimits)
 		if err == nil {
 			proxy.Transport = roundTripper
 		}
@@ -814,7 +859,7 @@ func convertToContext(contextName string, clientConfig *api.Config, source int,
 	authInfo := clientConfig.AuthInfos[context.AuthInfo]
 
 	// Make contextName DNS friendly.
-	contextName = makeDNSFriendly(contextName)
+	contextName = MakeDNSFriendly(contextName)
 
 	newContext := Context{
 		Name:        contextName,
@@ -850,7 +895,7 @@ func LoadContextsFromAPIConfig(config *api.Config, skipProxySetup bool) ([]Conte
 		authInfo := config.AuthInfos[context.AuthInfo]
 
 		// Make contextName DNS friendly.
-		contextName = makeDNSFriendly(contextName)
+		contextName = MakeDNSFriendly(contextName)
 
 		context := Context{
 			Name:        contextName,
@@ -993,8 +1038,8 @@ func LoadAndStoreKubeConfigs(kubeConfigStore ContextStore, kubeConfigs string, s
 	return errors.Join(errs...)
 }
 
-// makeDNSFriendly converts a string to a DNS-friendly format.
-func makeDNSFriendly(name string) string {
+// MakeDNSFriendly converts a string to a DNS-friendly format.
+func MakeDNSFriendly(name string) string {
 	name = strings.ReplaceAll(name, "/", "--")
 	name = strings.ReplaceAll(name, " ", "__")
 
@@ -1,7 +1,11 @@
 package telemetry
 
 import (
+	"context"
 	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strings"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -28,6 +32,12 @@ type Metrics struct {
 	ErrorCounter metric.Int64Counter
 	// KubeconfigRefreshCounter tracks the number of kubeconfig refresh operations
 	KubeconfigRefreshCounter metric.Int64Counter
+	// ExcludePaths lists request paths RequestCounterMiddleware does not record.
+	// An entry ending in "*" matches every path with that prefix.
+	ExcludePaths []string
+	// ClusterContext returns the cluster context a request targets, recorded by
+	// RequestCounterMiddleware as the ClusterContextKey label when not empty.
+	ClusterContext func(r *http.Request) string
 }
 
 // NewMetrics creates and registers a set of common application metrics.
@@ -121,9 +131,82 @@ func initApplicationMetrics(meter metric.Meter, metrics *Metrics) error {
 	return nil
 }
 
+// buildInfoMetric is the name of the gauge describing the running build.
+const buildInfoMetric = "headlamp_build_info"
+
+// RegisterBuildInfo registers the headlamp_build_info gauge. Its value is
+// always 1, and it is labeled with the version, the commit and the Go version
+// the server was built with.
+func RegisterBuildInfo(meter metric.Meter, version string) error {
+	attrs := metric.WithAttributes(buildInfoAttributes(version)...)
+
+	_, err := meter.Int64ObservableGauge(
+		buildInfoMetric,
+		metric.WithDescription("Build information of the running Headlamp server"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(1, attrs)
+
+			return nil
+		}),
+	)
+
+	return err
+}
+
+// buildInfoAttributes returns the labels of headlamp_build_info, taking the
+// commit from the VCS info embedded by the Go toolchain, if any.
+func buildInfoAttributes(version string) []attribute.KeyValue {
+	commit := "unknown"
+	modified := false
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				commit = setting.Value
+			case "vcs.modified":
+				modified = setting.Value == "true"
+			}
+		}
+	}
+
+	return []attribute.KeyValue{
+		attribute.String("version", version),
+		attribute.String("commit", commit),
+		attribute.Bool("modified", modified),
+		attribute.String("go_version", runtime.Version()),
+	}
+}
+
+// isPathExcluded returns true if path matches one of the ExcludePaths entries.
+func (m *Metrics) isPathExcluded(path string) bool {
+	for _, excluded := range m.ExcludePaths {
+		if prefix, ok := strings.CutSuffix(excluded, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if path == excluded {
+			return true
+		}
+	}
+
+	return false
+}
+
 // RequestCounterMiddleware creates HTTP middleware that tracks request metrics.
+// Requests to paths in ExcludePaths are passed through without being recorded.
 func (m *Metrics) RequestCounterMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Handlers like the cluster proxy rewrite the path, so it is kept as
+		// requested.
+		path := r.URL.Path
+
+		if m.isPathExcluded(path) {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
 		m.ActiveRequestsGauge.Add(r.Context(), 1)
 
 		wrapper := newResponseWriter(w)
@@ -131,10 +214,16 @@ func (m *Metrics) RequestCounterMiddleware(next http.Handler) http.Handler {
 		defer func() {
 			attrs := []attribute.KeyValue{
 				attribute.String("http.method", r.Method),
-				attribute.String("http.target", r.URL.Path),
+				attribute.String("http.target", path),
 				attribute.Int("http.status_code", wrapper.statusCode),
 			}
 
+			if m.ClusterContext != nil {
+				if cluster := m.ClusterContext(r); cluster != "" {
+					attrs = append(attrs, ClusterContextKey.String(cluster))
+				}
+			}
+
 			if rec := recover(); rec != nil {
 				wrapper.statusCode = http.StatusInternalServerError
 				attrs[2] = attribute.Int("http.status_code", http.StatusInternalServerError)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	tel "github.com/kubernetes-sigs/headlamp/backend/pkg/telemetry"
@@ -193,6 +194,239 @@ func TestRequestCounterMiddleware(t *testing.T) { //nolint:funlen // long functi
 	assert.True(t, activeRequestsFound, "Expected to find http.server.active_requests metric")
 }
 
+func TestRequestCounterMiddlewareExcludePaths(t *testing.T) {
+	provider, reader := setupTestMeter(t)
+	t.Cleanup(func() {
+		err := provider.Shutdown(context.Background())
+		if err != nil {
+			t.Logf("Failed to shutdown provider: %v", err)
+		}
+	})
+
+	metrics, err := tel.NewMetrics()
+	require.NoError(t, err)
+
+	metrics.ExcludePaths = []string{"/clusters/*", "/wsMultiplexer"}
+
+	handler := metrics.RequestCounterMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("OK"))
+	}))
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	ctx := context.Background()
+
+	for _, path := range []string{"/clusters/minikube/api/v1/pods", "/wsMultiplexer", "/config"} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+path, nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NoError(t, resp.Body.Close())
+	}
+
+	var data metricdata.ResourceMetrics
+	err = reader.Collect(context.Background(), &data)
+	require.NoError(t, err)
+
+	requestCountFound := false
+
+	for _, scopeMetric := range data.ScopeMetrics {
+		for _, m := range scopeMetric.Metrics {
+			if m.Name == metricRequestCount {
+				requestCountFound = true
+
+				assert.Equal(t, int64(1), sumDataPoints(m.Data), "Expected only /config to be recorded")
+			}
+		}
+	}
+
+	assert.True(t, requestCountFound, "Expected to find http.server.request_count metric")
+}
+
+func TestRequestCounterMiddlewareClusterContext(t *testing.T) {
+	provider, reader := setupTestMeter(t)
+	t.Cleanup(func() {
+		err := provider.Shutdown(context.Background())
+		if err != nil {
+			t.Logf("Failed to shutdown provider: %v", err)
+		}
+	})
+
+	metrics, err := tel.NewMetrics()
+	require.NoError(t, err)
+
+	metrics.ClusterContext = func(r *http.Request) string {
+		if strings.HasPrefix(r.URL.Path, "/clusters/minikube/") {
+			return "minikube"
+		}
+
+		return ""
+	}
+
+	handler := metrics.RequestCounterMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, path := range []string{"/clusters/minikube/api/v1/pods", "/config"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	var data metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &data))
+
+	clusters := map[string]string{}
+
+	for _, scopeMetric := range data.ScopeMetrics {
+		for _, m := range scopeMetric.Metrics {
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if m.Name != metricRequestCount || !ok {
+				continue
+			}
+
+			for _, dp := range sum.DataPoints {
+				target, _ := dp.Attributes.Value("http.target")
+				cluster, _ := dp.Attributes.Value(tel.ClusterContextKey)
+				clusters[target.AsString()] = cluster.AsString()
+			}
+		}
+	}
+
+	assert.Equal(t, map[string]string{"/clusters/minikube/api/v1/pods": "minikube", "/config": ""}, clusters)
+}
+
+func TestRequestCounterMiddlewareRewrittenPath(t *testing.T) {
+	provider, reader := setupTestMeter(t)
+	t.Cleanup(func() {
+		_ = provider.Shutdown(context.Background())
+	})
+
+	metrics, err := tel.NewMetrics()
+	require.NoError(t, err)
+
+	handler := metrics.RequestCounterMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.URL.Path = "/version"
+
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/clusters/minikube/version", nil))
+
+	var data metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &data))
+
+	targets := []string{}
+
+	for _, scopeMetric := range data.ScopeMetrics {
+		for _, m := range scopeMetric.Metrics {
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			if m.Name != metricRequestCount || !ok {
+				continue
+			}
+
+			for _, dp := range sum.DataPoints {
+				target, _ := dp.Attributes.Value("http.target")
+				targets = append(targets, target.AsString())
+			}
+		}
+	}
+
+	assert.Equal(t, []string{"/clusters/minikube/version"}, targets)
+}
+
+func TestRegisterBuildInfo(t *testing.T) {
+	provider, reader := setupTestMeter(t)
+	t.Cleanup(func() {
+		err := provider.Shutdown(context.Background())
+		if err != nil {
+			t.Logf("Failed to shutdown provider: %v", err)
+		}
+	})
+
+	err := tel.RegisterBuildInfo(otel.Meter("headlamp"), "1.2.3")
+	require.NoError(t, err)
+
+	var data metricdata.ResourceMetrics
+	err = reader.Collect(context.Background(), &data)
+	require.NoError(t, err)
+
+	buildInfoFound := false
+
+	for _, scopeMetric := range data.ScopeMetrics {
+		for _, m := range scopeMetric.Metrics {
+			if m.Name != "headlamp_build_info" {
+				continue
+			}
+
+			buildInfoFound = true
+
+			gauge, ok := m.Data.(metricdata.Gauge[int64])
+			require.True(t, ok)
+			require.Len(t, gauge.DataPoints, 1)
+			assert.Equal(t, int64(1), gauge.DataPoints[0].Value)
+
+			version, ok := gauge.DataPoints[0].Attributes.Value("version")
+			assert.True(t, ok)
+			assert.Equal(t, "1.2.3", version.AsString())
+
+			_, ok = gauge.DataPoints[0].Attributes.Value("commit")
+			assert.True(t, ok)
+		}
+	}
+
+	assert.True(t, buildInfoFound, "Expected to find headlamp_build_info metric")
+}
+
+func TestRequestLatencyView(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(reader),
+		sdkmetric.WithView(tel.RequestLatencyView([]float64{5, 50, 500})),
+	)
+
+	originalProvider := otel.GetMeterProvider()
+	otel.SetMeterProvider(provider)
+
+	t.Cleanup(func() {
+		otel.SetMeterProvider(originalProvider)
+
+		err := provider.Shutdown(context.Background())
+		if err != nil {
+			t.Logf("Failed to shutdown provider: %v", err)
+		}
+	})
+
+	metrics, err := tel.NewMetrics()
+	require.NoError(t, err)
+
+	metrics.RequestDuration.Record(context.Background(), 42)
+
+	var data metricdata.ResourceMetrics
+	err = reader.Collect(context.Background(), &data)
+	require.NoError(t, err)
+
+	durationFound := false
+
+	for _, scopeMetric := range data.ScopeMetrics {
+		for _, m := range scopeMetric.Metrics {
+			if m.Name != "http.server.duration" {
+				continue
+			}
+
+			durationFound = true
+
+			histogram, ok := m.Data.(metricdata.Histogram[float64])
+			require.True(t, ok)
+			require.Len(t, histogram.DataPoints, 1)
+			assert.Equal(t, []float64{5, 50, 500}, histogram.DataPoints[0].Bounds)
+			assert.Equal(t, []uint64{0, 1, 0, 0}, histogram.DataPoints[0].BucketCounts)
+		}
+	}
+
+	assert.True(t, durationFound, "Expected to find http.server.duration metric")
+}
+
 func TestRequestCounterMiddlewarePanic(t *testing.T) {
 	provider, reader := setupTestMeter(t)
 	defer func() {
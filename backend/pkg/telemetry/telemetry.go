@@ -107,10 +107,16 @@ func setupMetrics(t *Telemetry, res *resource.Resource) error {
 		return fmt.Errorf("failed to initialize Prometheus exporter: %w", err)
 	}
 
-	mp := metric.NewMeterProvider(
+	options := []metric.Option{
 		metric.WithReader(promExporter),
 		metric.WithResource(res),
-	)
+	}
+
+	if buckets := t.config.MetricsLatencyBucketList(); len(buckets) > 0 {
+		options = append(options, metric.WithView(RequestLatencyView(buckets)))
+	}
+
+	mp := metric.NewMeterProvider(options...)
 	if mp == nil {
 		return fmt.Errorf("meter provider initialization returned nil")
 	}
@@ -118,9 +124,24 @@ func setupMetrics(t *Telemetry, res *resource.Resource) error {
 	t.meterProvider = mp
 	otel.SetMeterProvider(mp)
 
+	if !t.config.DisableBuildInfoMetric {
+		if err := RegisterBuildInfo(mp.Meter("headlamp"), *t.config.ServiceVersion); err != nil {
+			return fmt.Errorf("failed to register build info metric: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// RequestLatencyView returns a view setting the bucket boundaries, in
+// milliseconds, of the request latency histogram.
+func RequestLatencyView(boundaries []float64) metric.View {
+	return metric.NewView(
+		metric.Instrument{Name: "http.server.duration"},
+		metric.Stream{Aggregation: metric.AggregationExplicitBucketHistogram{Boundaries: boundaries}},
+	)
+}
+
 // setupTracing initializes and configures the tracing components.
 // It creates the appropriate exporter based on configuration,
 // sets up a tracer provider with the configured sampling rate,
@@ -254,19 +275,26 @@ func createTracingExporter(cfg cfg.Config) (trace.SpanExporter, error) { //nolin
 // createOTLPExporter creates an OpenTelemetry Protocol (OTLP) exporter
 // that can send traces to compatible backends like Jaeger, etc
 // OTLP-compatible systems. It supports both HTTP and gRPC transport protocols.
+// TLS is only used for an https:// endpoint, see cfg.OTLPExportEndpoint.
 func createOTLPExporter(cfg cfg.Config) (trace.SpanExporter, error) {
 	var client otlptrace.Client
 
+	endpoint, insecure := cfg.OTLPExportEndpoint()
+
 	if *cfg.UseOTLPHTTP {
-		client = otlptracehttp.NewClient(
-			otlptracehttp.WithEndpoint(*cfg.OTLPEndpoint),
-			otlptracehttp.WithInsecure(),
-		)
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint)}
+		if insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+
+		client = otlptracehttp.NewClient(opts...)
 	} else {
-		client = otlptracegrpc.NewClient(
-			otlptracegrpc.WithEndpoint(*cfg.OTLPEndpoint),
-			otlptracegrpc.WithInsecure(),
-		)
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(endpoint)}
+		if insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+
+		client = otlptracegrpc.NewClient(opts...)
 	}
 
 	return otlptrace.New(context.Background(), client)
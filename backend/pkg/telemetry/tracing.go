@@ -15,6 +15,8 @@ import (
 // HTTP handlers with OpenTelemetry tracing.
 // The middleware creates spans for each HTTP request, propagates trace context
 // across service boundaries, and records request and response details as span events.
+// The query of a request is not recorded, as it may carry secrets such as
+// OIDC authorization codes.
 func TracingMiddleware(serviceName string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return otelhttp.NewHandler(next, serviceName,
@@ -27,6 +29,24 @@ func TracingMiddleware(serviceName string) func(http.Handler) http.Handler {
 	}
 }
 
+// ClusterContextKey is the attribute for the cluster context a request targets.
+const ClusterContextKey = attribute.Key("k8s.cluster.context")
+
+// ClusterContextMiddleware records the cluster context a request targets, as
+// returned by clusterContext, on the span of the request. It needs to run
+// after TracingMiddleware.
+func ClusterContextMiddleware(clusterContext func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if cluster := clusterContext(r); cluster != "" {
+				trace.SpanFromContext(r.Context()).SetAttributes(ClusterContextKey.String(cluster))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // StartSpan starts a new span with the given name and returns the context with the span.
 // This function creates a span using the specified TracerProvider, which enables proper
 // span attribution to the correct service or component. The context returned contains the created span,
@@ -85,6 +85,72 @@ func TestTracingMiddleware(t *testing.T) {
 	assert.True(t, containsAttribute("http.status_code", 200))
 }
 
+func TestTracingMiddlewareOmitsQuery(t *testing.T) {
+	sr, tp := setupTracingProvider(t)
+	originalTP := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+
+	defer otel.SetTracerProvider(originalTP)
+
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.URL.Query().Get("code"))
+		w.WriteHeader(http.StatusOK)
+	})
+
+	handler := tel.TracingMiddleware("test-service")(testHandler)
+
+	req := httptest.NewRequest("GET", "/oidc-callback?code=secret&state=xyz&x=1", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1, "Expected one span to be created")
+
+	for _, attr := range spans[0].Attributes() {
+		assert.NotContains(t, attr.Value.Emit(), "secret")
+		assert.NotContains(t, attr.Value.Emit(), "xyz")
+	}
+}
+
+func TestClusterContextMiddleware(t *testing.T) {
+	sr, tp := setupTracingProvider(t)
+	originalTP := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+
+	defer otel.SetTracerProvider(originalTP)
+
+	clusterContext := func(r *http.Request) string {
+		return r.URL.Query().Get("cluster")
+	}
+
+	handler := tel.TracingMiddleware("test-service")(tel.ClusterContextMiddleware(clusterContext)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})))
+
+	for _, target := range []string{"/?cluster=minikube", "/"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+
+	clusters := []string{}
+
+	for _, span := range spans {
+		cluster := ""
+
+		for _, attr := range span.Attributes() {
+			if attr.Key == tel.ClusterContextKey {
+				cluster = attr.Value.AsString()
+			}
+		}
+
+		clusters = append(clusters, cluster)
+	}
+
+	assert.Equal(t, []string{"minikube", ""}, clusters)
+}
+
 func TestTracingMiddlewareWithPropagation(t *testing.T) {
 	sr, tp := setupTracingProvider(t)
 	originalTP := otel.GetTracerProvider()
@@ -16,6 +16,11 @@ limitations under the License.
 
 package utils
 
+import (
+	"net/url"
+	"strings"
+)
+
 // Contains returns true if the slice contains the value.
 func Contains[T comparable](elems []T, v T) bool {
 	for _, s := range elems {
@@ -26,3 +31,31 @@ func Contains[T comparable](elems []T, v T) bool {
 
 	return false
 }
+
+// RedactedValue replaces the values of the query parameters hidden by RedactQuery.
+const RedactedValue = "REDACTED"
+
+// RedactQuery replaces the values of the given parameters in rawQuery with
+// RedactedValue, keeping the order of the parameters.
+func RedactQuery(rawQuery string, params []string) string {
+	if rawQuery == "" {
+		return rawQuery
+	}
+
+	pairs := strings.Split(rawQuery, "&")
+
+	for i, pair := range pairs {
+		key, _, _ := strings.Cut(pair, "=")
+
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			name = key
+		}
+
+		if Contains(params, name) {
+			pairs[i] = key + "=" + RedactedValue
+		}
+	}
+
+	return strings.Join(pairs, "&")
+}
@@ -33,3 +33,23 @@ func TestConatins(t *testing.T) {
 		t.Error("Expected false")
 	}
 }
+
+func TestRedactQuery(t *testing.T) {
+	t.Parallel()
+
+	params := []string{"code", "state"}
+
+	tests := map[string]string{
+		"":                          "",
+		"a=1&b=2":                   "a=1&b=2",
+		"code=secret&a=1&state=xyz": "code=REDACTED&a=1&state=REDACTED",
+		"co%64e=secret":             "co%64e=REDACTED",
+		"code":                      "code=REDACTED",
+	}
+
+	for rawQuery, want := range tests {
+		if got := utils.RedactQuery(rawQuery, params); got != want {
+			t.Errorf("RedactQuery(%q) = %q, want %q", rawQuery, got, want)
+		}
+	}
+}
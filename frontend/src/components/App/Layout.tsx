@@ -168,7 +168,11 @@ export default function Layout({}: LayoutProps) {
           clustersToConfig[cluster.name] = cluster;
         });
 
-        const configToStore = { ...config, clusters: clustersToConfig };
+        const configToStore = {
+          ...config,
+          clusters: clustersToConfig,
+          defaultCluster: config?.defaultCluster || null,
+        };
 
         if (clusters === null) {
           dispatch(setConfig(configToStore));
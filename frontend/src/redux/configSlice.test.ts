@@ -38,6 +38,24 @@ describe('configSlice', () => {
     expect(nextState.clusters).toEqual(clusters);
   });
 
+  it('should handle setConfig with a default cluster', () => {
+    const clusters: ConfigState['clusters'] = {
+      'cluster-1': { name: 'cluster-1' } as Cluster,
+    };
+    let nextState = configReducer(
+      initialState,
+      setConfig({ clusters, defaultCluster: 'cluster-1' })
+    );
+    expect(nextState.defaultCluster).toEqual('cluster-1');
+
+    // Configs without a default cluster keep the current one.
+    nextState = configReducer(nextState, setConfig({ clusters }));
+    expect(nextState.defaultCluster).toEqual('cluster-1');
+
+    nextState = configReducer(nextState, setConfig({ clusters, defaultCluster: null }));
+    expect(nextState.defaultCluster).toBeNull();
+  });
+
   it('should handle setStatelessConfig', () => {
     const statelessClusters: ConfigState['statelessClusters'] = {
       'stateless-1': { name: 'stateless-1' } as Cluster,
@@ -41,6 +41,12 @@ export interface ConfigState {
   allClusters: {
     [clusterName: string]: Cluster;
   } | null;
+  /**
+   * Default cluster is the cluster active by default, i.e. the current-context
+   * of the kubeconfig or the backend's default-context-fallback.
+   * Null indicates that there is none.
+   */
+  defaultCluster: string | null;
   /**
    * Settings is a map of settings names to settings values.
    */
@@ -70,6 +76,7 @@ export const initialState: ConfigState = {
   clusters: null,
   statelessClusters: null,
   allClusters: null,
+  defaultCluster: null,
   settings: {
     tableRowsPerPageOptions:
       storedSettings.tableRowsPerPageOptions || defaultTableRowsPerPageOptions,
@@ -88,8 +95,17 @@ const configSlice = createSlice({
      * @param state - The current state.
      * @param action - The payload action containing the config.
      */
-    setConfig(state, action: PayloadAction<{ clusters: ConfigState['clusters'] }>) {
+    setConfig(
+      state,
+      action: PayloadAction<{
+        clusters: ConfigState['clusters'];
+        defaultCluster?: ConfigState['defaultCluster'];
+      }>
+    ) {
       state.clusters = action.payload.clusters;
+      if (action.payload.defaultCluster !== undefined) {
+        state.defaultCluster = action.payload.defaultCluster;
+      }
     },
     /**
      * Save the config. To both the store, and localStorage.